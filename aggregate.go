@@ -0,0 +1,205 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNoElement is the error of the terminal operations which need an element on an empty Stream.
+var ErrNoElement = errors.New("no element")
+
+// TopK Returns a Stream of the k largest elements ordered by less, the largest one comes first.
+// Only k elements are held at a time.
+func (s *Stream) TopK(k int, less LessFunc) *Stream {
+	if k < 1 {
+		panic("k should be greater than 0")
+	}
+	return s.stage("TopK", 0, func(ctx context.Context, source chan interface{}) {
+		s.topK(ctx, k, less, source)
+	})
+}
+
+// BottomK Returns a Stream of the k smallest elements ordered by less, the smallest one comes first.
+// Only k elements are held at a time.
+func (s *Stream) BottomK(k int, less LessFunc) *Stream {
+	if k < 1 {
+		panic("k should be greater than 0")
+	}
+	return s.stage("BottomK", 0, func(ctx context.Context, source chan interface{}) {
+		s.topK(ctx, k, func(a, b interface{}) bool {
+			return less(b, a)
+		}, source)
+	})
+}
+
+// topK sends the k largest elements ordered by less into source, the largest one comes first.
+func (s *Stream) topK(ctx context.Context, k int, less LessFunc, source chan<- interface{}) {
+	// h is a min-heap, the smallest of the largest elements is replaced by a larger one.
+	h := &boundedHeap{less: less}
+	for {
+		item, ok := receive(ctx, s.source)
+		if !ok {
+			break
+		}
+		if h.Len() < k {
+			heap.Push(h, item)
+		} else if less(h.items[0], item) {
+			h.items[0] = item
+			heap.Fix(h, 0)
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	items := make([]interface{}, h.Len())
+	for i := len(items) - 1; i >= 0; i-- {
+		items[i] = heap.Pop(h)
+	}
+	for _, item := range items {
+		source <- item
+	}
+}
+
+// A boundedHeap is a min-heap of the elements ordered by less, it implements heap.Interface.
+type boundedHeap struct {
+	items []interface{}
+	less  LessFunc
+}
+
+func (h *boundedHeap) Len() int {
+	return len(h.items)
+}
+
+func (h *boundedHeap) Less(i, j int) bool {
+	return h.less(h.items[i], h.items[j])
+}
+
+func (h *boundedHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+}
+
+func (h *boundedHeap) Push(x interface{}) {
+	h.items = append(h.items, x)
+}
+
+func (h *boundedHeap) Pop() interface{} {
+	n := len(h.items)
+	item := h.items[n-1]
+	h.items[n-1] = nil
+	h.items = h.items[:n-1]
+	return item
+}
+
+// Min Returns the smallest element ordered by less, and ErrNoElement if the Stream is empty or the error of the Stream.
+// The first one of the smallest elements is returned.
+func (s *Stream) Min(less LessFunc) (interface{}, error) {
+	smallest, _, err := s.MinMax(less)
+	return smallest, err
+}
+
+// Max Returns the largest element ordered by less, and ErrNoElement if the Stream is empty or the error of the Stream.
+// The first one of the largest elements is returned.
+func (s *Stream) Max(less LessFunc) (interface{}, error) {
+	_, largest, err := s.MinMax(less)
+	return largest, err
+}
+
+// MinMax Returns the smallest and the largest elements ordered by less,
+// and ErrNoElement if the Stream is empty or the error of the Stream.
+func (s *Stream) MinMax(less LessFunc) (smallest, largest interface{}, err error) {
+	found := false
+	for item := range s.open() {
+		if !found {
+			smallest, largest, found = item, item, true
+			continue
+		}
+		if less(item, smallest) {
+			smallest = item
+		}
+		if less(largest, item) {
+			largest = item
+		}
+	}
+	if err = s.Err(); err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, ErrNoElement
+	}
+	return smallest, largest, nil
+}
+
+// Sum Returns the sum of the numeric elements, which are of the integer or floating-point types,
+// and the error of the Stream or of the element which is not a number. The sum of an empty Stream is 0.
+func (s *Stream) Sum() (float64, error) {
+	sum, _, err := s.sum()
+	return sum, err
+}
+
+// Average Returns the average of the numeric elements, which are of the integer or floating-point types,
+// and ErrNoElement if the Stream is empty or the error of the Stream or of the element which is not a number.
+func (s *Stream) Average() (float64, error) {
+	sum, count, err := s.sum()
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, ErrNoElement
+	}
+	return sum / float64(count), nil
+}
+
+// sum Returns the sum and the number of the numeric elements.
+func (s *Stream) sum() (sum float64, count int, err error) {
+	source := s.open()
+	defer s.cancel()
+	for item := range source {
+		v, err := toFloat64(item)
+		if err != nil {
+			return 0, 0, err
+		}
+		sum += v
+		count++
+	}
+	if err = s.Err(); err != nil {
+		return 0, 0, err
+	}
+	return sum, count, nil
+}
+
+// toFloat64 converts the number item to float64.
+func toFloat64(item interface{}) (float64, error) {
+	v := reflect.ValueOf(item)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	default:
+		return 0, fmt.Errorf("stream: element %v of type %T is not a number", item, item)
+	}
+}
@@ -0,0 +1,87 @@
+package stream
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func lessInt(a, b interface{}) bool {
+	return a.(int) < b.(int)
+}
+
+func TestStream_TopK(t *testing.T) {
+	equal(t, Of(5, 1, 4, 2, 3).TopK(3, lessInt), []interface{}{5, 4, 3})
+	equal(t, Of(2, 1).TopK(3, lessInt), []interface{}{2, 1})
+	equal(t, Empty().TopK(3, lessInt), []interface{}{})
+	assert.Panics(t, func() {
+		Of(1).TopK(0, lessInt)
+	})
+}
+
+func TestStream_BottomK(t *testing.T) {
+	equal(t, Of(5, 1, 4, 2, 3).BottomK(2, lessInt), []interface{}{1, 2})
+	equal(t, Of(3, 3, 1).BottomK(5, lessInt), []interface{}{1, 3, 3})
+	assert.Panics(t, func() {
+		Of(1).BottomK(0, lessInt)
+	})
+}
+
+func TestStream_MinMax(t *testing.T) {
+	smallest, largest, err := Of(3, 1, 4, 1, 5).MinMax(lessInt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, smallest)
+	assert.Equal(t, 5, largest)
+
+	smallest, err = Of(3, 1, 4).Min(lessInt)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, smallest)
+	largest, err = Of(3, 1, 4).Max(lessInt)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, largest)
+
+	_, err = Empty().Min(lessInt)
+	assert.Equal(t, ErrNoElement, err)
+	_, err = Empty().Max(lessInt)
+	assert.Equal(t, ErrNoElement, err)
+
+	errMap := errors.New("map")
+	_, err = Of(1).MapErr(func(item interface{}) (interface{}, error) {
+		return nil, errMap
+	}).Min(lessInt)
+	assert.Equal(t, errMap, err)
+}
+
+func TestStream_Sum(t *testing.T) {
+	sum, err := Of(1, int8(2), uint(3), 1.5, float32(0.5)).Sum()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(8), sum)
+
+	sum, err = Empty().Sum()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), sum)
+
+	_, err = Of(1, "a").Sum()
+	assert.EqualError(t, err, "stream: element a of type string is not a number")
+
+	stream, exited := infinite()
+	_, err = stream.Map(func(item interface{}) interface{} {
+		if item.(int) == 3 {
+			return "a"
+		}
+		return item
+	}).Sum()
+	assert.Error(t, err)
+	<-exited
+}
+
+func TestStream_Average(t *testing.T) {
+	average, err := Of(1, 2, 3, 4).Average()
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, average)
+
+	_, err = Empty().Average()
+	assert.Equal(t, ErrNoElement, err)
+	_, err = Of("a").Average()
+	assert.Error(t, err)
+}
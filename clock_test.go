@@ -0,0 +1,174 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// A fakeClock is a Clock whose time is moved by Advance.
+type fakeClock struct {
+	lock sync.Mutex
+	cond *sync.Cond
+	now  time.Time
+	// step is added to the time every time Now is called.
+	step    time.Duration
+	timers  []*fakeTimer
+	created int
+	nows    int
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	c      chan time.Time
+	at     time.Time
+	period time.Duration
+	active bool
+}
+
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{now: time.Unix(0, 0)}
+	c.cond = sync.NewCond(&c.lock)
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(c.step)
+	c.nows++
+	c.cond.Broadcast()
+	return now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	return c.newTimer(d, 0)
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	return fakeTicker{c.newTimer(d, d)}
+}
+
+func (c *fakeClock) newTimer(d, period time.Duration) *fakeTimer {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1), at: c.now.Add(d), period: period, active: true}
+	t.fire(c.now)
+	c.timers = append(c.timers, t)
+	c.created++
+	c.cond.Broadcast()
+	return t
+}
+
+// Advance moves the time forward by d and fires the timers which are due.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		t.fire(c.now)
+	}
+}
+
+// waitTimers waits until n timers and tickers are created.
+func (c *fakeClock) waitTimers(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for c.created < n {
+		c.cond.Wait()
+	}
+}
+
+// waitFired waits until the values sent by the active timers and tickers are received.
+func (c *fakeClock) waitFired() {
+	for {
+		c.lock.Lock()
+		fired := false
+		for _, t := range c.timers {
+			fired = fired || t.active && len(t.c) != 0
+		}
+		c.lock.Unlock()
+		if !fired {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+// waitNow waits until Now is called n times.
+func (c *fakeClock) waitNow(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for c.nows < n {
+		c.cond.Wait()
+	}
+}
+
+func (t *fakeTimer) fire(now time.Time) {
+	if !t.active || t.at.After(now) {
+		return
+	}
+	select {
+	case t.c <- t.at:
+	default:
+	}
+	if t.period == 0 {
+		t.active = false
+		return
+	}
+	for !t.at.After(now) {
+		t.at = t.at.Add(t.period)
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.lock.Lock()
+	defer t.clock.lock.Unlock()
+
+	active := t.active
+	t.active = false
+	return active
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
+
+func TestSystemClock(t *testing.T) {
+	clock := SystemClock()
+	assert.WithinDuration(t, time.Now(), clock.Now(), time.Second)
+
+	timer := clock.NewTimer(time.Millisecond)
+	<-timer.C()
+	assert.False(t, timer.Stop())
+
+	ticker := clock.NewTicker(time.Millisecond)
+	<-ticker.C()
+	<-ticker.C()
+	ticker.Stop()
+}
+
+func TestWithClock(t *testing.T) {
+	clock := newFakeClock()
+	withClock := WithClock(clock)
+	ops := new(Options)
+	withClock(ops)
+	assert.Equal(t, &Options{clock: clock}, ops)
+	assert.Equal(t, SystemClock(), loadOptions().clock)
+}
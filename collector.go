@@ -0,0 +1,192 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Collector accumulates the elements of a Stream into a container, and transforms the container into the result.
+type Collector struct {
+	supplier    func() interface{}
+	accumulator AccumulateFunc
+	finisher    MapFunc
+}
+
+// NewCollector Returns a Collector, supplier creates the container, accumulator accumulates an element into
+// the container and returns the container, finisher transforms the container into the result.
+// The result is the container if finisher is nil.
+func NewCollector(supplier func() interface{}, accumulator AccumulateFunc, finisher MapFunc) Collector {
+	if finisher == nil {
+		finisher = func(container interface{}) interface{} {
+			return container
+		}
+	}
+	return Collector{
+		supplier:    supplier,
+		accumulator: accumulator,
+		finisher:    finisher,
+	}
+}
+
+// ToSlice Returns a Collector that collects the elements into a []interface{}.
+func ToSlice() Collector {
+	return NewCollector(func() interface{} {
+		return make([]interface{}, 0)
+	}, func(acc, item interface{}) interface{} {
+		return append(acc.([]interface{}), item)
+	}, nil)
+}
+
+// ToMap Returns a Collector that collects the elements into a map[interface{}]interface{},
+// the keys and values are produced by key and value.
+// If the keys are duplicated, the values are merged by merge, or the later value is kept if merge is nil.
+func ToMap(key KeyFunc, value MapFunc, merge func(a, b interface{}) interface{}) Collector {
+	return NewCollector(func() interface{} {
+		return make(map[interface{}]interface{})
+	}, func(acc, item interface{}) interface{} {
+		m := acc.(map[interface{}]interface{})
+		k, v := key(item), value(item)
+		if old, ok := m[k]; ok && merge != nil {
+			v = merge(old, v)
+		}
+		m[k] = v
+		return m
+	}, nil)
+}
+
+// GroupingBy Returns a Collector that groups the elements by key into a map[interface{}]interface{},
+// the elements of each group are collected by downstream.
+func GroupingBy(key KeyFunc, downstream Collector) Collector {
+	return NewCollector(func() interface{} {
+		return make(map[interface{}]interface{})
+	}, func(acc, item interface{}) interface{} {
+		m := acc.(map[interface{}]interface{})
+		k := key(item)
+		container, ok := m[k]
+		if !ok {
+			container = downstream.supplier()
+		}
+		m[k] = downstream.accumulator(container, item)
+		return m
+	}, func(container interface{}) interface{} {
+		m := container.(map[interface{}]interface{})
+		for k, v := range m {
+			m[k] = downstream.finisher(v)
+		}
+		return m
+	})
+}
+
+// PartitioningBy Returns a Collector that partitions the elements by predicate into a map[bool]interface{},
+// the elements of each partition are collected by downstream. Both the partitions are always in the result.
+func PartitioningBy(predicate FilterFunc, downstream Collector) Collector {
+	return NewCollector(func() interface{} {
+		return map[bool]interface{}{
+			true:  downstream.supplier(),
+			false: downstream.supplier(),
+		}
+	}, func(acc, item interface{}) interface{} {
+		m := acc.(map[bool]interface{})
+		k := predicate(item)
+		m[k] = downstream.accumulator(m[k], item)
+		return m
+	}, func(container interface{}) interface{} {
+		m := container.(map[bool]interface{})
+		for k, v := range m {
+			m[k] = downstream.finisher(v)
+		}
+		return m
+	})
+}
+
+// Joining Returns a Collector that joins the elements formatted by fmt.Sprint with sep into a string.
+func Joining(sep string) Collector {
+	return NewCollector(func() interface{} {
+		return new(strings.Builder)
+	}, func(acc, item interface{}) interface{} {
+		builder := acc.(*strings.Builder)
+		if builder.Len() != 0 {
+			builder.WriteString(sep)
+		}
+		builder.WriteString(fmt.Sprint(item))
+		return builder
+	}, func(container interface{}) interface{} {
+		return container.(*strings.Builder).String()
+	})
+}
+
+// Counting Returns a Collector that counts the elements into an int.
+func Counting() Collector {
+	return NewCollector(func() interface{} {
+		return 0
+	}, func(acc, item interface{}) interface{} {
+		return acc.(int) + 1
+	}, nil)
+}
+
+// Summing Returns a Collector that sums the numbers produced by mapper from the elements into a float64.
+func Summing(mapper func(item interface{}) float64) Collector {
+	return NewCollector(func() interface{} {
+		return float64(0)
+	}, func(acc, item interface{}) interface{} {
+		return acc.(float64) + mapper(item)
+	}, nil)
+}
+
+// Collect Returns the result of collecting all the elements by collector, and the error of the Stream.
+func (s *Stream) Collect(collector Collector) (interface{}, error) {
+	container := collector.supplier()
+	for item := range s.open() {
+		container = collector.accumulator(container, item)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return collector.finisher(container), nil
+}
+
+// Reduce Returns the result of reducing all the elements by fn, and the error of fn or the Stream.
+// The upstream is stopped once fn returns.
+func (s *Stream) Reduce(fn ReduceFunc) (interface{}, error) {
+	source := s.open()
+	result, err := fn(source)
+	s.cancel()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Fold Returns the result of accumulating all the elements into identity by accumulator,
+// and the error of the Stream, like the reduce with an identity of Java Stream.
+func (s *Stream) Fold(identity interface{}, accumulator AccumulateFunc) (interface{}, error) {
+	acc := identity
+	for item := range s.open() {
+		acc = accumulator(acc, item)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestToSlice(t *testing.T) {
+	result, err := Of(1, 2, 3).Collect(ToSlice())
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2, 3}, result)
+
+	result, err = Empty().Collect(ToSlice())
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{}, result)
+}
+
+func TestToMap(t *testing.T) {
+	key := func(item interface{}) interface{} {
+		return item.(string)[:1]
+	}
+	value := func(item interface{}) interface{} {
+		return item
+	}
+	result, err := Of("a1", "b1", "a2").Collect(ToMap(key, value, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, map[interface{}]interface{}{"a": "a2", "b": "b1"}, result)
+
+	result, err = Of("a1", "b1", "a2").Collect(ToMap(key, value, func(a, b interface{}) interface{} {
+		return a.(string) + b.(string)
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, map[interface{}]interface{}{"a": "a1a2", "b": "b1"}, result)
+}
+
+func TestGroupingBy(t *testing.T) {
+	result, err := Of(1, 2, 3, 4, 5).Collect(GroupingBy(func(item interface{}) interface{} {
+		return item.(int) % 2
+	}, Counting()))
+	assert.NoError(t, err)
+	assert.Equal(t, map[interface{}]interface{}{0: 2, 1: 3}, result)
+
+	result, err = Of("a", "bb", "c").Collect(GroupingBy(func(item interface{}) interface{} {
+		return len(item.(string))
+	}, Joining(",")))
+	assert.NoError(t, err)
+	assert.Equal(t, map[interface{}]interface{}{1: "a,c", 2: "bb"}, result)
+}
+
+func TestPartitioningBy(t *testing.T) {
+	result, err := Of(1, 2, 3).Collect(PartitioningBy(func(item interface{}) bool {
+		return item.(int) > 1
+	}, ToSlice()))
+	assert.NoError(t, err)
+	assert.Equal(t, map[bool]interface{}{true: []interface{}{2, 3}, false: []interface{}{1}}, result)
+
+	result, err = Empty().Collect(PartitioningBy(func(item interface{}) bool {
+		return true
+	}, Counting()))
+	assert.NoError(t, err)
+	assert.Equal(t, map[bool]interface{}{true: 0, false: 0}, result)
+}
+
+func TestJoining(t *testing.T) {
+	result, err := Of(1, "a", 2.5).Collect(Joining(", "))
+	assert.NoError(t, err)
+	assert.Equal(t, "1, a, 2.5", result)
+}
+
+func TestCounting(t *testing.T) {
+	result, err := Of(1, 2, 3).Collect(Counting())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result)
+}
+
+func TestSumming(t *testing.T) {
+	result, err := Of(1, 2, 3).Collect(Summing(func(item interface{}) float64 {
+		return float64(item.(int))
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, float64(6), result)
+}
+
+func TestStream_Collect(t *testing.T) {
+	errMap := errors.New("map")
+	result, err := Of(1).MapErr(func(item interface{}) (interface{}, error) {
+		return nil, errMap
+	}).Collect(ToSlice())
+	assert.Equal(t, errMap, err)
+	assert.Nil(t, result)
+}
+
+func TestStream_Reduce(t *testing.T) {
+	result, err := Of(1, 2, 3).Reduce(func(pipe <-chan interface{}) (interface{}, error) {
+		sum := 0
+		for item := range pipe {
+			sum += item.(int)
+		}
+		return sum, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, result)
+
+	errReduce := errors.New("reduce")
+	stream, exited := infinite()
+	result, err = stream.Reduce(func(pipe <-chan interface{}) (interface{}, error) {
+		<-pipe
+		return nil, errReduce
+	})
+	<-exited
+	assert.Equal(t, errReduce, err)
+	assert.Nil(t, result)
+}
+
+func TestStream_Fold(t *testing.T) {
+	result, err := Of(1, 2, 3).Fold(10, func(acc, item interface{}) interface{} {
+		return acc.(int) + item.(int)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 16, result)
+
+	result, err = Empty().Fold("identity", func(acc, item interface{}) interface{} {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "identity", result)
+}
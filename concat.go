@@ -0,0 +1,131 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"container/heap"
+	"context"
+)
+
+// ConcatOrdered Returns a Stream that contains all the elements of a, then all the elements of others in order.
+func ConcatOrdered(a *Stream, others ...*Stream) *Stream {
+	return a.ConcatOrdered(others...)
+}
+
+// MergeSorted Returns a Stream that merges the Streams sorted by less into one sorted Stream.
+// Only the head element of each Stream is held at a time.
+func MergeSorted(less LessFunc, streams ...*Stream) *Stream {
+	if len(streams) == 0 {
+		return Empty()
+	}
+	return streams[0].MergeSorted(less, streams[1:]...)
+}
+
+// ConcatOrdered Returns a Stream that contains all the elements of s, then all the elements of others in order,
+// each Stream is drained before the next one is read.
+func (s *Stream) ConcatOrdered(others ...*Stream) *Stream {
+	streams := append([]*Stream{s}, others...)
+	return newStage(s.pipeline, "ConcatOrdered", 0, func(ctx context.Context, source chan interface{}) {
+		for _, stream := range streams {
+			for {
+				item, ok := receive(ctx, stream.source)
+				if !ok {
+					break
+				}
+				source <- item
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}, streams...)
+}
+
+// MergeSorted Returns a Stream that merges s and others sorted by less into one sorted Stream, see MergeSorted.
+// The elements which are equal are emitted in the order of the Streams.
+func (s *Stream) MergeSorted(less LessFunc, others ...*Stream) *Stream {
+	streams := append([]*Stream{s}, others...)
+	return newStage(s.pipeline, "MergeSorted", 0, func(ctx context.Context, source chan interface{}) {
+		h := &mergeHeap{less: less}
+		for i, stream := range streams {
+			item, ok := receive(ctx, stream.source)
+			if ok {
+				h.heads = append(h.heads, mergeHead{item: item, index: i})
+			}
+		}
+		heap.Init(h)
+
+		for h.Len() != 0 {
+			if ctx.Err() != nil {
+				return
+			}
+			head := h.heads[0]
+			source <- head.item
+			item, ok := receive(ctx, streams[head.index].source)
+			if ok {
+				h.heads[0].item = item
+				heap.Fix(h, 0)
+			} else {
+				heap.Pop(h)
+			}
+		}
+	}, streams...)
+}
+
+// A mergeHead is the head element of a Stream merged by MergeSorted.
+type mergeHead struct {
+	item  interface{}
+	index int
+}
+
+// A mergeHeap is a min-heap of the head elements, it implements heap.Interface.
+type mergeHeap struct {
+	heads []mergeHead
+	less  LessFunc
+}
+
+func (h *mergeHeap) Len() int {
+	return len(h.heads)
+}
+
+func (h *mergeHeap) Less(i, j int) bool {
+	if h.less(h.heads[i].item, h.heads[j].item) {
+		return true
+	}
+	if h.less(h.heads[j].item, h.heads[i].item) {
+		return false
+	}
+	return h.heads[i].index < h.heads[j].index
+}
+
+func (h *mergeHeap) Swap(i, j int) {
+	h.heads[i], h.heads[j] = h.heads[j], h.heads[i]
+}
+
+func (h *mergeHeap) Push(x interface{}) {
+	h.heads = append(h.heads, x.(mergeHead))
+}
+
+func (h *mergeHeap) Pop() interface{} {
+	n := len(h.heads)
+	head := h.heads[n-1]
+	h.heads[n-1] = mergeHead{}
+	h.heads = h.heads[:n-1]
+	return head
+}
@@ -0,0 +1,37 @@
+package stream
+
+import "testing"
+
+func TestConcatOrdered(t *testing.T) {
+	equal(t, ConcatOrdered(Of(1, 2), Of(3), Empty(), Of(4, 5)), []interface{}{1, 2, 3, 4, 5})
+	equal(t, Of(1).ConcatOrdered(), []interface{}{1})
+
+	stream, exited := infinite()
+	equal(t, Of(-1).ConcatOrdered(stream).Limit(3), []interface{}{-1, 0, 1})
+	<-exited
+}
+
+func TestMergeSorted(t *testing.T) {
+	less := func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}
+	equal(t, MergeSorted(less, Of(1, 4, 7), Of(2, 5, 8), Of(3, 6, 9)), []interface{}{1, 2, 3, 4, 5, 6, 7, 8, 9})
+	equal(t, MergeSorted(less, Of(1, 1, 3), Empty(), Of(0, 2)), []interface{}{0, 1, 1, 2, 3})
+	equal(t, MergeSorted(less), []interface{}{})
+
+	// the equal elements are emitted in the order of the Streams.
+	type entry struct {
+		key   int
+		value string
+	}
+	stream := Of(entry{1, "a"}, entry{2, "a"}).MergeSorted(func(a, b interface{}) bool {
+		return a.(entry).key < b.(entry).key
+	}, Of(entry{1, "b"}, entry{2, "b"}))
+	equal(t, stream, []interface{}{entry{1, "a"}, entry{1, "b"}, entry{2, "a"}, entry{2, "b"}})
+
+	infinite1, exited1 := infinite()
+	infinite2, exited2 := infinite()
+	equal(t, MergeSorted(less, infinite1, infinite2).Limit(4), []interface{}{0, 0, 1, 1})
+	<-exited1
+	<-exited2
+}
@@ -0,0 +1,178 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"time"
+)
+
+// DistinctLRU Returns a Stream like Distinct, but only the capacity keys seen most recently are remembered,
+// the element whose key is forgotten is emitted again.
+func (s *Stream) DistinctLRU(f KeyFunc, capacity int) *Stream {
+	if capacity < 1 {
+		panic("capacity should be greater than 0")
+	}
+	return s.applyState("DistinctLRU", func() applyFunc {
+		recent := list.New()
+		keys := make(map[interface{}]*list.Element, capacity)
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
+			k := f(item)
+			if e, ok := keys[k]; ok {
+				recent.MoveToFront(e)
+				return nil
+			}
+
+			emit(item)
+			keys[k] = recent.PushFront(k)
+			if recent.Len() > capacity {
+				delete(keys, recent.Remove(recent.Back()))
+			}
+			return nil
+		}
+	})
+}
+
+// DistinctWithin Returns a Stream like Distinct, but a key is forgotten ttl after its element is emitted,
+// the element whose key is forgotten is emitted again.
+func (s *Stream) DistinctWithin(f KeyFunc, ttl time.Duration, opts ...Option) *Stream {
+	if ttl <= 0 {
+		panic("ttl should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	type seen struct {
+		key interface{}
+		at  time.Time
+	}
+	return s.applyState("DistinctWithin", func() applyFunc {
+		keys := make(map[interface{}]time.Time)
+		// queue holds the keys in the order they are seen, to forget them once they expire.
+		var queue []seen
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
+			now := option.clock.Now()
+			for len(queue) != 0 && now.Sub(queue[0].at) >= ttl {
+				if at, ok := keys[queue[0].key]; ok && at.Equal(queue[0].at) {
+					delete(keys, queue[0].key)
+				}
+				queue[0] = seen{}
+				queue = queue[1:]
+			}
+
+			k := f(item)
+			if _, ok := keys[k]; ok {
+				return nil
+			}
+			emit(item)
+			keys[k] = now
+			queue = append(queue, seen{key: k, at: now})
+			return nil
+		}
+	})
+}
+
+// DistinctApprox Returns a Stream like Distinct, but the keys are remembered by a Bloom filter sized for
+// expectedItems keys with the false positive rate, so the memory is bounded,
+// while an element may be dropped by mistake at about the rate.
+func (s *Stream) DistinctApprox(f KeyFunc, expectedItems int, falsePositiveRate float64) *Stream {
+	if expectedItems < 1 {
+		panic("expectedItems should be greater than 0")
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		panic("falsePositiveRate should be in (0, 1)")
+	}
+	return s.applyState("DistinctApprox", func() applyFunc {
+		filter := newBloomFilter(expectedItems, falsePositiveRate)
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
+			if filter.add(hashKey(f(item))) {
+				emit(item)
+			}
+			return nil
+		}
+	})
+}
+
+// A bloomFilter tells whether a key may have been added.
+type bloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// newBloomFilter returns a bloomFilter sized for n keys with the false positive rate p.
+func newBloomFilter(n int, p float64) *bloomFilter {
+	size := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(size)/float64(n)*math.Ln2)))
+	return &bloomFilter{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+	}
+}
+
+// add adds the key of hash h, and reports whether the key is new, a key may be taken as added by mistake.
+func (b *bloomFilter) add(h uint64) bool {
+	// the hashes are derived from the two halves of h by double hashing.
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	isNew := false
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % b.size
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[word]&mask == 0 {
+			isNew = true
+			b.bits[word] |= mask
+		}
+	}
+	return isNew
+}
+
+// hashKey returns the 64-bit FNV-1a hash of key, the keys of different types have different hashes.
+// The hash is finalized as MurmurHash3 does, since FNV-1a mixes the short keys poorly.
+func hashKey(key interface{}) uint64 {
+	h := fnv.New64a()
+	var buf [binary.MaxVarintLen64]byte
+	switch k := key.(type) {
+	case string:
+		h.Write([]byte{'s'})
+		h.Write([]byte(k))
+	case int:
+		h.Write([]byte{'i'})
+		h.Write(buf[:binary.PutVarint(buf[:], int64(k))])
+	case int64:
+		h.Write([]byte{'l'})
+		h.Write(buf[:binary.PutVarint(buf[:], k)])
+	case uint64:
+		h.Write([]byte{'u'})
+		h.Write(buf[:binary.PutUvarint(buf[:], k)])
+	default:
+		_, _ = fmt.Fprintf(h, "%T:%v", key, key)
+	}
+
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
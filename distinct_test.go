@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func identity(item interface{}) interface{} {
+	return item
+}
+
+func TestStream_DistinctLRU(t *testing.T) {
+	equal(t, Of(1, 2, 1, 3, 2, 1).DistinctLRU(identity, 3), []interface{}{1, 2, 3})
+	// 2 is forgotten when 3 is seen, since 1 is seen more recently.
+	equal(t, Of(1, 2, 1, 3, 2, 1).DistinctLRU(identity, 2), []interface{}{1, 2, 3, 2, 1})
+	assert.Panics(t, func() {
+		Of(1).DistinctLRU(identity, 0)
+	})
+}
+
+func TestStream_DistinctWithin(t *testing.T) {
+	clock := newFakeClock()
+	clock.step = time.Second
+	// the elements are seen at 0s, 1s, 2s...
+	equal(t, Of(1, 2, 1, 1, 2, 1).DistinctWithin(identity, 3*time.Second, WithClock(clock)),
+		[]interface{}{1, 2, 1, 2})
+	assert.Panics(t, func() {
+		Of(1).DistinctWithin(identity, 0)
+	})
+}
+
+func TestStream_DistinctApprox(t *testing.T) {
+	equal(t, Of(1, "1", 2, 1, "1", 3).DistinctApprox(identity, 100, 0.01), []interface{}{1, "1", 2, 3})
+
+	filter := newBloomFilter(2000, 0.01)
+	for i := 0; i < 1000; i++ {
+		filter.add(hashKey(i))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.False(t, filter.add(hashKey(i)))
+	}
+	// the rate is at most 1% until 2000 keys are added.
+	falsePositives := 0
+	for i := 1000; i < 2000; i++ {
+		if !filter.add(hashKey(-i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 20)
+
+	assert.Panics(t, func() {
+		Of(1).DistinctApprox(identity, 0, 0.01)
+	})
+	assert.Panics(t, func() {
+		Of(1).DistinctApprox(identity, 1, 1)
+	})
+}
+
+func TestHashKey(t *testing.T) {
+	assert.Equal(t, hashKey(1), hashKey(1))
+	assert.Equal(t, hashKey("a"), hashKey("a"))
+	assert.Equal(t, hashKey(1.5), hashKey(1.5))
+	assert.NotEqual(t, hashKey(1), hashKey("1"))
+	assert.NotEqual(t, hashKey(1), hashKey(int64(1)))
+	assert.NotEqual(t, hashKey(uint64(1)), hashKey(int64(1)))
+}
@@ -0,0 +1,255 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"sync"
+)
+
+// applyFunc defines the method to handle an element, the results are emitted by emit.
+type applyFunc func(ctx context.Context, item interface{}, emit func(interface{})) error
+
+// applyWalk adapts f to an applyFunc, the results sent into the pipe of f are emitted by a relay,
+// the relays are reused by the elements, and they are all stopped once release is called.
+func applyWalk(f WalkErrContextFunc) (fn applyFunc, release func()) {
+	relays := &relays{}
+	return func(ctx context.Context, item interface{}, emit func(interface{})) error {
+		r := relays.get()
+		defer relays.put(r)
+		return r.walk(ctx, f, item, emit)
+	}, relays.close
+}
+
+// A relay emits the elements sent into its pipe from a goroutine of its own.
+type relay struct {
+	pipe  chan interface{}
+	emits chan func(interface{})
+	end   chan struct{}
+}
+
+// newRelay Returns a relay whose goroutine runs until its emits is closed.
+func newRelay() *relay {
+	r := &relay{
+		pipe:  make(chan interface{}),
+		emits: make(chan func(interface{})),
+		end:   make(chan struct{}),
+	}
+	go func() {
+		for emit := range r.emits {
+			r.relay(emit)
+		}
+	}()
+	return r
+}
+
+// relay emits the elements sent into the pipe of r until the end of the element is received.
+func (r *relay) relay(emit func(interface{})) {
+	for {
+		select {
+		case v := <-r.pipe:
+			emit(v)
+		case <-r.end:
+			return
+		}
+	}
+}
+
+// walk calls f to handle item with the pipe of r, the elements sent into the pipe are emitted by emit.
+func (r *relay) walk(ctx context.Context, f WalkErrContextFunc, item interface{}, emit func(interface{})) error {
+	r.emits <- emit
+	// the pipe is unbuffered, so all the elements sent by f are emitted once the end is received.
+	defer func() {
+		r.end <- struct{}{}
+	}()
+	return f(ctx, item, r.pipe)
+}
+
+// relays holds the idle relays, which are stopped once relays is closed.
+type relays struct {
+	mu     sync.Mutex
+	idle   []*relay
+	closed bool
+}
+
+// get Returns an idle relay, or a new one if there is no idle relay.
+func (rs *relays) get() *relay {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if n := len(rs.idle); n > 0 {
+		r := rs.idle[n-1]
+		rs.idle = rs.idle[:n-1]
+		return r
+	}
+	return newRelay()
+}
+
+// put makes r idle, r is stopped if rs is closed.
+func (rs *relays) put(r *relay) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.closed {
+		close(r.emits)
+		return
+	}
+	rs.idle = append(rs.idle, r)
+}
+
+// close stops the idle relays, and the others once they are idle.
+func (rs *relays) close() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.closed = true
+	for _, r := range rs.idle {
+		close(r.emits)
+	}
+	rs.idle = nil
+}
+
+// A fusedOperator is an operator fused into a stage with the others.
+type fusedOperator struct {
+	operator string
+	// newFn returns the function of the operator once the stage running it is started.
+	newFn  func() applyFunc
+	option *Options
+	// input is the Stream whose elements are handled by the operator.
+	input *Stream
+}
+
+// apply Returns a Stream that lets operator handle each item by fn.
+// If the operator is sequential, it is fused into the stage of s when s is fused too,
+// so that the adjacent sequential operators are run in a single goroutine.
+func (s *Stream) apply(operator string, fn applyFunc, opts ...Option) *Stream {
+	return s.applyState(operator, func() applyFunc {
+		return fn
+	}, opts...)
+}
+
+// applyState Returns a Stream like apply, the function of the stateful operator is returned by newFn
+// once the stage running it is started.
+func (s *Stream) applyState(operator string, newFn func() applyFunc, opts ...Option) *Stream {
+	option := loadOptions(opts...)
+	if option.workSize > 1 || option.workerPool != nil {
+		return s.walk(operator, option, newFn(), nil)
+	}
+
+	operators := []fusedOperator{{operator: operator, newFn: newFn, option: option, input: s}}
+	if s.fused != nil && !s.opened.Load() {
+		operators = append(append([]fusedOperator(nil), s.fused...), operators...)
+	}
+	s.downstreams.Add(1)
+
+	stream := &Stream{
+		pipeline: s.pipeline,
+		fused:    operators,
+		upstream: operators[0].input,
+	}
+	stream.start = func() (<-chan interface{}, context.CancelFunc) {
+		operators := unfuse(operators)
+		upstream := operators[0].input
+		upstream.open()
+		return startStage(s.pipeline, operators[0].operator, 0, func(ctx context.Context, source chan interface{}) {
+			upstream.runFused(ctx, operators, source)
+		}, []*Stream{upstream}, true)
+	}
+	return stream
+}
+
+// unfuse Returns the tail of operators which can be run in a single stage.
+// An operator is fused with the operators after it only if its Stream has no other downstreams and
+// it is not opened, and then its Stream is consumed, so that its elements are never handled twice.
+// The Streams which have many downstreams are left unfused, so that their stages are shared.
+func unfuse(operators []fusedOperator) []fusedOperator {
+	i := len(operators) - 1
+	for i > 0 && operators[i].input.consume() {
+		i--
+	}
+	return operators[i:]
+}
+
+// consume marks s as consumed by its only downstream, which runs the stage of s by itself.
+// It returns false if s has many downstreams or it is opened, a consumed Stream is empty if it is opened.
+func (s *Stream) consume() (consumed bool) {
+	if s.downstreams.Load() != 1 {
+		return false
+	}
+	s.once.Do(func() {
+		s.opened.Store(true)
+		s.source = empty
+		s.cancel = func() {}
+		consumed = true
+	})
+	return
+}
+
+// runFused applies the operators to the elements of s in turn, and sends the results into source.
+func (s *Stream) runFused(ctx context.Context, operators []fusedOperator, source chan<- interface{}) {
+	// the results of each operator are buffered, so that the panics of the operators are not mixed up.
+	buffers := make([][]interface{}, len(operators))
+	fns := make([]applyFunc, len(operators))
+	for i, operator := range operators {
+		fns[i] = operator.newFn()
+	}
+	var process func(i int, item interface{})
+	process = func(i int, item interface{}) {
+		if i == len(operators) {
+			source <- item
+			return
+		}
+
+		if operators[i].option.wait(ctx) != nil {
+			return
+		}
+		results := buffers[i][:0]
+		s.handle(operators[i].operator, operators[i].option, item, func() error {
+			return operators[i].option.attempt(ctx, fns[i], item, func(v interface{}) {
+				results = append(results, v)
+			})
+		})
+		buffers[i] = results
+		for _, result := range results {
+			process(i+1, result)
+		}
+		clear(results)
+	}
+
+	for {
+		item, ok := receive(ctx, s.source)
+		if !ok {
+			return
+		}
+		process(0, item)
+	}
+}
+
+// handle calls f to let operator handle item safely, the panics and errors are handled according to option.
+func (s *Stream) handle(operator string, option *Options, item interface{}, f func() error) {
+	// better to safely run caller defined method
+	defer func() {
+		if r := recover(); r != nil {
+			if err := option.handlePanic(operator, item, r); err != nil {
+				s.pipeline.fail(err, option.errorMode == FailFast)
+			}
+		}
+	}()
+
+	if err := f(); err != nil {
+		s.pipeline.fail(err, option.errorMode == FailFast)
+	}
+}
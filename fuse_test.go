@@ -0,0 +1,155 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/multierr"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestStream_Lazy(t *testing.T) {
+	started := false
+	stream := From(func(source chan<- interface{}) {
+		started = true
+		source <- 1
+	}).Map(func(item interface{}) interface{} {
+		return item
+	}).Buffer(1)
+	runtime.Gosched()
+	assert.False(t, started)
+	equal(t, stream, []interface{}{1})
+	assert.True(t, started)
+}
+
+func TestStream_Fuse(t *testing.T) {
+	source := Of(1, 2, 3, 4, 5, 6)
+	stream := source.Map(func(item interface{}) interface{} {
+		return item.(int) * 2
+	}).Filter(func(item interface{}) bool {
+		return item.(int)%3 != 0
+	}).Peek(func(item interface{}) {
+	}).Skip(1).Distinct(func(item interface{}) interface{} {
+		return item
+	}).Map(func(item interface{}) interface{} {
+		return item.(int) + 1
+	})
+	assert.Equal(t, source, stream.upstream)
+	operators := make([]string, 0, len(stream.fused))
+	for _, fused := range stream.fused {
+		operators = append(operators, fused.operator)
+	}
+	assert.Equal(t, []string{"Map", "Filter", "Peek", "Skip", "Distinct", "Map"}, operators)
+	equal(t, stream, []interface{}{5, 9, 11})
+
+	// the parallel operators are not fused.
+	parallel := source.Map(func(item interface{}) interface{} {
+		return item
+	}, WithWorkSize(2))
+	assert.Nil(t, parallel.fused)
+	fused := parallel.Filter(func(item interface{}) bool {
+		return true
+	})
+	assert.Equal(t, parallel, fused.upstream)
+
+	// the opened Streams are not fused.
+	stream = Of(1, 2).Map(func(item interface{}) interface{} {
+		return item
+	})
+	stream.Chan()
+	fused = stream.Map(func(item interface{}) interface{} {
+		return item
+	})
+	assert.Equal(t, stream, fused.upstream)
+	equal(t, fused, []interface{}{1, 2})
+}
+
+func TestStream_FuseFailure(t *testing.T) {
+	var recovered []interface{}
+	stream := Of(1, 2, 3, 4).Map(func(item interface{}) interface{} {
+		if item.(int) == 2 {
+			panic("two")
+		}
+		return item
+	}, WithPanicHandler(func(item, r interface{}) {
+		recovered = append(recovered, r)
+	})).FlatMap(func(item interface{}) interface{} {
+		if item.(int) == 3 {
+			panic("three")
+		}
+		return item
+	}, WithPanicHandler(func(item, r interface{}) {
+		recovered = append(recovered, r)
+	}))
+	equal(t, stream, []interface{}{1, 4})
+	assert.Equal(t, []interface{}{"two", "three"}, recovered)
+
+	errOdd := errors.New("odd")
+	stream = Of(1, 2, 3, 4).FilterErr(func(item interface{}) (bool, error) {
+		if item.(int)%2 == 1 {
+			return false, errOdd
+		}
+		return true, nil
+	}, WithErrorMode(CollectAll)).MapErr(func(item interface{}) (interface{}, error) {
+		return item, nil
+	})
+	equal(t, stream, []interface{}{2, 4})
+	assert.Len(t, multierr.Errors(stream.Err()), 2)
+}
+
+func TestStream_FuseBranches(t *testing.T) {
+	items := make(chan interface{})
+	source := Range(items).Skip(10)
+	identity := func(item interface{}) interface{} {
+		return item
+	}
+	// Skip is not fused into the branches, which share its stage.
+	branches := []<-chan interface{}{source.Map(identity).Chan(), source.Map(identity).Chan()}
+	counts := make(chan int, len(branches))
+	for _, branch := range branches {
+		go func(branch <-chan interface{}) {
+			count := 0
+			for range branch {
+				count++
+			}
+			counts <- count
+		}(branch)
+	}
+	for i := 0; i < 100; i++ {
+		items <- i
+	}
+	close(items)
+	assert.Equal(t, 90, <-counts+<-counts)
+
+	// the Stream fused into its only downstream is consumed.
+	source = Of(1, 2, 3).Skip(1)
+	stream := source.Map(identity)
+	equal(t, stream, []interface{}{2, 3})
+	assert.Equal(t, 0, source.Count())
+}
+
+func TestApplyWalk(t *testing.T) {
+	before := runtime.NumGoroutine()
+	fn, release := applyWalk(func(ctx context.Context, item interface{}, pipe chan<- interface{}) error {
+		pipe <- item
+		pipe <- item
+		return nil
+	})
+	var results []interface{}
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, fn(context.Background(), i, func(v interface{}) {
+			results = append(results, v)
+		}))
+		// the relay is reused by the elements.
+		assert.LessOrEqual(t, runtime.NumGoroutine(), before+1)
+	}
+	assert.Equal(t, []interface{}{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9}, results)
+
+	release()
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
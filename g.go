@@ -18,7 +18,7 @@
 
 package stream
 
-import "go.uber.org/zap"
+import "runtime/debug"
 
 // Recover Recover when panic.
 func Recover(cleanups ...func()) {
@@ -27,7 +27,7 @@ func Recover(cleanups ...func()) {
 	}
 
 	if e := recover(); e != nil {
-		Log.Error("stream failed", zap.Any("error", e))
+		currentLogger().Error("stream failed", "error", e, "stack", string(debug.Stack()))
 	}
 }
 
@@ -36,3 +36,9 @@ func NewGoroutine(f func()) {
 	defer Recover()
 	f()
 }
+
+// recoverOperator logs the panic recovered from the goroutine of operator, and fails p fast with a PanicError.
+func recoverOperator(p *pipeline, operator string, recovered interface{}) {
+	currentLogger().Error("stream failed", "operator", operator, "error", recovered, "stack", string(debug.Stack()))
+	p.fail(&PanicError{Recovered: recovered}, true)
+}
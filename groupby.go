@@ -0,0 +1,182 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// A GroupedStream is a Stream of the elements which have the same Key.
+type GroupedStream struct {
+	Key interface{}
+	*Stream
+}
+
+// WithInsertionOrder return a Option that emits the groups of Group in the order their keys are first seen
+func WithInsertionOrder() Option {
+	return func(options *Options) {
+		options.insertionOrder = true
+	}
+}
+
+// WithGroupIdle return a Option that closes the groups of GroupByStream which receive no element within idle,
+// the groups are checked every idle
+func WithGroupIdle(idle time.Duration) Option {
+	return func(options *Options) {
+		options.groupIdle = idle
+	}
+}
+
+// WithMaxGroups return a Option that limits the open groups of GroupByStream to n,
+// the group which receives no element for the longest time is closed to open a new one
+func WithMaxGroups(n int) Option {
+	return func(options *Options) {
+		options.maxGroups = n
+	}
+}
+
+// GroupByStream Returns a Stream of GroupedStream, a GroupedStream is emitted when its key is first seen,
+// and the later elements of the key are sent to it. The GroupedStreams are emitted in the order their keys
+// are first seen, they should be consumed concurrently since the elements are sent to them in turn.
+// A group is closed when the Stream ends, or it is evicted by the option WithGroupIdle or WithMaxGroups,
+// then a new GroupedStream of the key is emitted if the key is seen again.
+// The elements of a GroupedStream which is stopped, such as by Limit, are dropped.
+func (s *Stream) GroupByStream(f KeyFunc, opts ...Option) *Stream {
+	option := loadOptions(opts...)
+	if option.groupIdle < 0 {
+		panic("idle should be greater than -1")
+	}
+	if option.maxGroups < 0 {
+		panic("max groups should be greater than -1")
+	}
+	return s.stage("GroupByStream", 0, func(ctx context.Context, source chan interface{}) {
+		router := &groupRouter{
+			stream: s,
+			groups: make(map[interface{}]*list.Element),
+			recent: list.New(),
+			clock:  option.clock,
+		}
+		defer router.closeAll()
+
+		var tick <-chan time.Time
+		if option.groupIdle > 0 {
+			ticker := option.clock.NewTicker(option.groupIdle)
+			defer ticker.Stop()
+			tick = ticker.C()
+		}
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					return
+				}
+				key := f(item)
+				g, isNew := router.group(key)
+				if isNew {
+					if option.maxGroups > 0 && router.recent.Len() > option.maxGroups {
+						router.close(router.recent.Front())
+					}
+					source <- GroupedStream{Key: key, Stream: g.stream}
+				}
+				select {
+				case g.source <- item:
+				case <-g.stopped:
+				case <-ctx.Done():
+					return
+				}
+			case now := <-tick:
+				router.evict(now.Add(-option.groupIdle))
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// A groupRouter holds the open groups of GroupByStream.
+type groupRouter struct {
+	stream *Stream
+	groups map[interface{}]*list.Element
+	// recent holds the groups in the order they receive the elements, the idlest one comes first.
+	recent *list.List
+	clock  Clock
+}
+
+// A group is an open group of GroupByStream.
+type group struct {
+	key      interface{}
+	source   chan interface{}
+	stream   *Stream
+	stopped  chan struct{}
+	lastSeen time.Time
+}
+
+// group returns the group of key which receives an element, isNew is true if the group is opened for it.
+func (r *groupRouter) group(key interface{}) (g *group, isNew bool) {
+	now := r.clock.Now()
+	if e, ok := r.groups[key]; ok {
+		g = e.Value.(*group)
+		g.lastSeen = now
+		r.recent.MoveToBack(e)
+		return g, false
+	}
+
+	g = &group{
+		key:      key,
+		source:   make(chan interface{}),
+		stopped:  make(chan struct{}),
+		lastSeen: now,
+	}
+	var once sync.Once
+	g.stream = &Stream{
+		source:   g.source,
+		pipeline: r.stream.pipeline,
+		cancel: func() {
+			once.Do(func() {
+				close(g.stopped)
+			})
+		},
+	}
+	r.groups[key] = r.recent.PushBack(g)
+	return g, true
+}
+
+// evict closes the groups which receive no element after deadline.
+func (r *groupRouter) evict(deadline time.Time) {
+	for e := r.recent.Front(); e != nil && !e.Value.(*group).lastSeen.After(deadline); e = r.recent.Front() {
+		r.close(e)
+	}
+}
+
+// close closes the group of e.
+func (r *groupRouter) close(e *list.Element) {
+	g := r.recent.Remove(e).(*group)
+	delete(r.groups, g.key)
+	close(g.source)
+}
+
+// closeAll closes all the groups.
+func (r *groupRouter) closeAll() {
+	for e := r.recent.Front(); e != nil; e = r.recent.Front() {
+		r.close(e)
+	}
+}
@@ -0,0 +1,95 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+// groups Returns the keys of the GroupedStreams in order, and their elements.
+func groups(s *Stream) (keys []interface{}, items map[interface{}][]interface{}) {
+	items = make(map[interface{}][]interface{})
+	var (
+		lock sync.Mutex
+		wg   sync.WaitGroup
+	)
+	for item := range s.Chan() {
+		g := item.(GroupedStream)
+		keys = append(keys, g.Key)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := range g.Chan() {
+				lock.Lock()
+				items[g.Key] = append(items[g.Key], v)
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return
+}
+
+func TestStream_GroupByStream(t *testing.T) {
+	keys, items := groups(Of(1, 2, 3, 4, 5, 6).GroupByStream(func(item interface{}) interface{} {
+		return item.(int) % 3
+	}))
+	assert.Equal(t, []interface{}{1, 2, 0}, keys)
+	assert.Equal(t, map[interface{}][]interface{}{1: {1, 4}, 2: {2, 5}, 0: {3, 6}}, items)
+
+	keys, items = groups(Of(1, 2, 1, 1).GroupByStream(identity, WithMaxGroups(1)))
+	assert.Equal(t, []interface{}{1, 2, 1}, keys)
+	assert.Equal(t, map[interface{}][]interface{}{1: {1, 1, 1}, 2: {2}}, items)
+
+	assert.Panics(t, func() {
+		Of(1).GroupByStream(identity, WithMaxGroups(-1))
+	})
+	assert.Panics(t, func() {
+		Of(1).GroupByStream(identity, WithGroupIdle(-1))
+	})
+}
+
+func TestStream_GroupByStreamIdle(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	source := Range(upstream).GroupByStream(identity, WithGroupIdle(time.Minute), WithClock(clock)).Chan()
+
+	upstream <- "a"
+	g := (<-source).(GroupedStream)
+	assert.Equal(t, "a", g.Key)
+	assert.Equal(t, "a", <-g.Chan())
+	clock.Advance(time.Minute)
+	_, ok := <-g.Chan()
+	assert.False(t, ok)
+
+	upstream <- "a"
+	g = (<-source).(GroupedStream)
+	assert.Equal(t, "a", <-g.Chan())
+	close(upstream)
+	_, ok = <-g.Chan()
+	assert.False(t, ok)
+	_, ok = <-source
+	assert.False(t, ok)
+}
+
+func TestStream_GroupByStreamStop(t *testing.T) {
+	source := Of(1, 1, 1, 2).GroupByStream(identity).Chan()
+	g := (<-source).(GroupedStream)
+	equal(t, g.Stream.Limit(1), []interface{}{1})
+	g = (<-source).(GroupedStream)
+	equal(t, g.Stream, []interface{}{2})
+}
+
+func TestStream_GroupInsertionOrder(t *testing.T) {
+	equal(t, Of(3, 1, 2, 3, 1).Group(identity, WithInsertionOrder()), []interface{}{
+		[]interface{}{3, 3}, []interface{}{1, 1}, []interface{}{2},
+	})
+}
+
+func TestWithInsertionOrder(t *testing.T) {
+	withInsertionOrder := WithInsertionOrder()
+	ops := new(Options)
+	withInsertionOrder(ops)
+	assert.Equal(t, &Options{insertionOrder: true}, ops)
+}
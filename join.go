@@ -0,0 +1,233 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"time"
+)
+
+// JoinFunc defines the method to combine the matched elements of two Streams,
+// left or right is nil if there is no matched element in outer joins.
+type JoinFunc func(left, right interface{}) interface{}
+
+// JoinMode defines which elements are kept by Join if they are not matched.
+type JoinMode int
+
+const (
+	// InnerJoin drops the elements which are not matched.
+	InnerJoin JoinMode = iota
+	// LeftJoin keeps the elements of the left Stream which are not matched.
+	LeftJoin
+	// RightJoin keeps the elements of the right Stream which are not matched.
+	RightJoin
+	// FullOuterJoin keeps the elements of both the Streams which are not matched.
+	FullOuterJoin
+)
+
+// WithJoinMode return a Option that set the mode of Join, it is InnerJoin by default
+func WithJoinMode(mode JoinMode) Option {
+	return func(options *Options) {
+		options.joinMode = mode
+	}
+}
+
+// Join Returns a Stream of the elements of s and other which have the same key combined by combine,
+// the keys of the elements of s are got by leftKey, and the keys of the elements of other are got by rightKey.
+// It is a hash join, the Streams are read in turn until one of them ends, whose elements are held in a hash table,
+// then the elements of the other one are matched as they are read. Use WithJoinMode to keep the unmatched elements.
+func (s *Stream) Join(other *Stream, leftKey, rightKey KeyFunc, combine JoinFunc, opts ...Option) *Stream {
+	option := loadOptions(opts...)
+	outer := [2]bool{
+		option.joinMode == LeftJoin || option.joinMode == FullOuterJoin,
+		option.joinMode == RightJoin || option.joinMode == FullOuterJoin,
+	}
+	return newStage(s.pipeline, "Join", 0, func(ctx context.Context, source chan interface{}) {
+		sides := [2]*Stream{s, other}
+		keys := [2]KeyFunc{leftKey, rightKey}
+		// emit combines the elements in the order of the sides.
+		emit := func(side int, item, matched interface{}) {
+			if side == 0 {
+				source <- combine(item, matched)
+			} else {
+				source <- combine(matched, item)
+			}
+		}
+
+		var items [2][]interface{}
+		build := 0
+		for side := 0; ; side ^= 1 {
+			item, ok := receive(ctx, sides[side].source)
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+				build = side
+				break
+			}
+			items[side] = append(items[side], item)
+		}
+
+		table := make(map[interface{}][]int)
+		for i, item := range items[build] {
+			key := keys[build](item)
+			table[key] = append(table[key], i)
+		}
+		matched := make([]bool, len(items[build]))
+
+		probe := 1 - build
+		handle := func(item interface{}) {
+			indexes, ok := table[keys[probe](item)]
+			if !ok {
+				if outer[probe] {
+					emit(probe, item, nil)
+				}
+				return
+			}
+			for _, i := range indexes {
+				matched[i] = true
+				emit(probe, item, items[build][i])
+			}
+		}
+		for _, item := range items[probe] {
+			handle(item)
+		}
+		items[probe] = nil
+		for {
+			item, ok := receive(ctx, sides[probe].source)
+			if !ok {
+				break
+			}
+			handle(item)
+		}
+
+		if !outer[build] || ctx.Err() != nil {
+			return
+		}
+		for i, item := range items[build] {
+			if !matched[i] {
+				emit(build, item, nil)
+			}
+		}
+	}, s, other)
+}
+
+// A JoinWindow limits the elements held by WindowJoin for each key.
+type JoinWindow struct {
+	// Count is the max number of the elements held for each key of each Stream, no limit if it is 0.
+	Count int
+	// Duration is how long an element is held, no limit if it is 0.
+	Duration time.Duration
+}
+
+// WindowJoin Returns a Stream of the elements of s and other which have the same key combined by combine, like
+// an inner Join, but an element is only matched with the elements of the other Stream held in window.
+// The Streams are read concurrently, so it works with the unbounded Streams.
+func (s *Stream) WindowJoin(other *Stream, leftKey, rightKey KeyFunc, combine JoinFunc, window JoinWindow, opts ...Option) *Stream {
+	if window.Count <= 0 && window.Duration <= 0 {
+		panic("window should be limited by Count or Duration")
+	}
+	option := loadOptions(opts...)
+	type held struct {
+		item interface{}
+		at   time.Time
+		seq  uint64
+	}
+	type expiry struct {
+		side int
+		key  interface{}
+		at   time.Time
+		seq  uint64
+	}
+	return newStage(s.pipeline, "WindowJoin", 0, func(ctx context.Context, source chan interface{}) {
+		sides := [2]<-chan interface{}{s.source, other.source}
+		keys := [2]KeyFunc{leftKey, rightKey}
+		buffers := [2]map[interface{}][]held{make(map[interface{}][]held), make(map[interface{}][]held)}
+		// expiries holds the elements in the order they are received, to evict them once they are out of window.
+		var expiries []expiry
+		var seq uint64
+
+		remove := func(side int, key interface{}) {
+			buffer := buffers[side][key]
+			buffer[0] = held{}
+			if len(buffer) == 1 {
+				delete(buffers[side], key)
+			} else {
+				buffers[side][key] = buffer[1:]
+			}
+		}
+		evict := func(now time.Time) {
+			for len(expiries) != 0 && now.Sub(expiries[0].at) >= window.Duration {
+				e := expiries[0]
+				expiries[0] = expiry{}
+				expiries = expiries[1:]
+				// the element may have been evicted by Count.
+				if buffer := buffers[e.side][e.key]; len(buffer) != 0 && buffer[0].seq == e.seq {
+					remove(e.side, e.key)
+				}
+			}
+		}
+
+		for sides[0] != nil || sides[1] != nil {
+			var (
+				side int
+				item interface{}
+				ok   bool
+			)
+			select {
+			case item, ok = <-sides[0]:
+				side = 0
+			case item, ok = <-sides[1]:
+				side = 1
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				sides[side] = nil
+				continue
+			}
+
+			now := option.clock.Now()
+			if window.Duration > 0 {
+				evict(now)
+			}
+			key := keys[side](item)
+			for _, h := range buffers[1-side][key] {
+				if side == 0 {
+					source <- combine(item, h.item)
+				} else {
+					source <- combine(h.item, item)
+				}
+			}
+
+			// the element is not held if the other Stream ended, since nothing can be matched with it.
+			if sides[1-side] == nil {
+				continue
+			}
+			seq++
+			buffers[side][key] = append(buffers[side][key], held{item: item, at: now, seq: seq})
+			if window.Count > 0 && len(buffers[side][key]) > window.Count {
+				remove(side, key)
+			}
+			if window.Duration > 0 {
+				expiries = append(expiries, expiry{side: side, key: key, at: now, seq: seq})
+			}
+		}
+	}, s, other)
+}
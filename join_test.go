@@ -0,0 +1,111 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type joinEntry struct {
+	key   int
+	value string
+}
+
+func joinKey(item interface{}) interface{} {
+	return item.(joinEntry).key
+}
+
+func joinPair(left, right interface{}) interface{} {
+	return [2]interface{}{left, right}
+}
+
+func TestStream_Join(t *testing.T) {
+	a1, b2, c2 := joinEntry{1, "a"}, joinEntry{2, "b"}, joinEntry{2, "c"}
+	x2, y3 := joinEntry{2, "x"}, joinEntry{3, "y"}
+	join := func(mode JoinMode) interface{} {
+		result, err := Of(a1, b2, c2).Join(Of(x2, y3), joinKey, joinKey, joinPair, WithJoinMode(mode)).Collect(ToSlice())
+		assert.NoError(t, err)
+		return result
+	}
+
+	assert.ElementsMatch(t, []interface{}{
+		[2]interface{}{b2, x2}, [2]interface{}{c2, x2},
+	}, join(InnerJoin))
+	assert.ElementsMatch(t, []interface{}{
+		[2]interface{}{a1, nil}, [2]interface{}{b2, x2}, [2]interface{}{c2, x2},
+	}, join(LeftJoin))
+	assert.ElementsMatch(t, []interface{}{
+		[2]interface{}{b2, x2}, [2]interface{}{c2, x2}, [2]interface{}{nil, y3},
+	}, join(RightJoin))
+	assert.ElementsMatch(t, []interface{}{
+		[2]interface{}{a1, nil}, [2]interface{}{b2, x2}, [2]interface{}{c2, x2}, [2]interface{}{nil, y3},
+	}, join(FullOuterJoin))
+
+	result, err := Empty().Join(Of(x2), joinKey, joinKey, joinPair, WithJoinMode(RightJoin)).Collect(ToSlice())
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{[2]interface{}{nil, x2}}, result)
+}
+
+func TestStream_JoinUnbounded(t *testing.T) {
+	// the finite Stream is held, and the infinite one is matched as it is read.
+	stream, exited := infinite()
+	joined := stream.Join(Of(1, 3), func(item interface{}) interface{} {
+		return item
+	}, func(item interface{}) interface{} {
+		return item
+	}, func(left, right interface{}) interface{} {
+		return left
+	}).Limit(2)
+	equal(t, joined, []interface{}{1, 3})
+	<-exited
+}
+
+func TestStream_WindowJoin(t *testing.T) {
+	left := make(chan interface{})
+	right := make(chan interface{})
+	source := Range(left).WindowJoin(Range(right), joinKey, joinKey, joinPair, JoinWindow{Count: 2}).Chan()
+
+	a1, b1, c1 := joinEntry{1, "a"}, joinEntry{1, "b"}, joinEntry{1, "c"}
+	x1, y2 := joinEntry{1, "x"}, joinEntry{2, "y"}
+	left <- a1
+	left <- b1
+	left <- c1
+	right <- x1
+	// a1 is evicted since only 2 elements are held for each key.
+	assert.Equal(t, [2]interface{}{b1, x1}, <-source)
+	assert.Equal(t, [2]interface{}{c1, x1}, <-source)
+	right <- y2
+	close(left)
+	close(right)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		Of(1).WindowJoin(Of(1), joinKey, joinKey, joinPair, JoinWindow{})
+	})
+}
+
+func TestStream_WindowJoinDuration(t *testing.T) {
+	clock := newFakeClock()
+	clock.step = time.Second
+	left := make(chan interface{})
+	right := make(chan interface{})
+	source := Range(left).WindowJoin(Range(right), joinKey, joinKey, joinPair,
+		JoinWindow{Duration: 3 * time.Second}, WithClock(clock)).Chan()
+
+	a1, b1 := joinEntry{1, "a"}, joinEntry{1, "b"}
+	x1, y1 := joinEntry{1, "x"}, joinEntry{1, "y"}
+	// received at 0s, 1s, 2s and 3s.
+	left <- a1
+	left <- b1
+	right <- x1
+	assert.Equal(t, [2]interface{}{a1, x1}, <-source)
+	assert.Equal(t, [2]interface{}{b1, x1}, <-source)
+	// a1 is evicted at 3s.
+	right <- y1
+	assert.Equal(t, [2]interface{}{b1, y1}, <-source)
+	close(left)
+	close(right)
+	_, ok := <-source
+	assert.False(t, ok)
+}
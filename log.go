@@ -1,6 +1,94 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
 package stream
 
-import "go.uber.org/zap"
+import (
+	"log/slog"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+// Logger defines the method to log the failures of a Stream.
+type Logger interface {
+	// Error logs a message with the alternating keys and values.
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// loggerHolder holds a Logger, so that the Loggers of different types can be stored in a atomic.Value.
+type loggerHolder struct {
+	Logger
+}
+
+// logger the Logger shared by all the Streams.
+var logger atomic.Value
+
+func init() {
+	zapLogger, _ := zap.NewProduction()
+	SetLogger(NewZapLogger(zapLogger))
+}
+
+// SetLogger sets the Logger shared by all the Streams,
+// a Stream can use its own Logger by the option WithLogger.
+func SetLogger(l Logger) {
+	logger.Store(loggerHolder{l})
+}
+
+// currentLogger returns the Logger shared by all the Streams.
+func currentLogger() Logger {
+	return logger.Load().(loggerHolder).Logger
+}
+
+type zapLogger struct {
+	logger *zap.SugaredLogger
+}
+
+// NewZapLogger returns a Logger that logs with the zap logger.
+func NewZapLogger(logger *zap.Logger) Logger {
+	return zapLogger{logger: logger.Sugar()}
+}
+
+// Error logs a message with the alternating keys and values.
+func (l zapLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.logger.Errorw(msg, keysAndValues...)
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger that logs with the slog logger.
+func NewSlogLogger(logger *slog.Logger) Logger {
+	return slogLogger{logger: logger}
+}
+
+// Error logs a message with the alternating keys and values.
+func (l slogLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.logger.Error(msg, keysAndValues...)
+}
+
+type nopLogger struct{}
+
+// NewNopLogger returns a Logger that logs nothing.
+func NewNopLogger() Logger {
+	return nopLogger{}
+}
 
-// Log A log.
-var Log, _ = zap.NewProduction()
+// Error logs nothing.
+func (nopLogger) Error(string, ...interface{}) {}
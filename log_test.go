@@ -0,0 +1,74 @@
+package stream
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordLogger struct {
+	lock          sync.Mutex
+	msg           string
+	keysAndValues []interface{}
+}
+
+func (l *recordLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.msg = msg
+	l.keysAndValues = keysAndValues
+}
+
+func (l *recordLogger) entry() (string, []interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.msg, l.keysAndValues
+}
+
+func TestSetLogger(t *testing.T) {
+	defer SetLogger(currentLogger())
+
+	logger := new(recordLogger)
+	SetLogger(logger)
+	assert.Equal(t, logger, currentLogger())
+
+	NewGoroutine(func() {
+		panic("panic")
+	})
+	assert.Equal(t, "stream failed", logger.msg)
+	assert.Equal(t, []interface{}{"error", "panic"}, logger.keysAndValues[:2])
+
+	Of(1, 2).Sort(func(a, b interface{}) bool {
+		panic("sort")
+	}).Finish()
+	assert.Eventually(t, func() bool {
+		_, keysAndValues := logger.entry()
+		return len(keysAndValues) > 0 && keysAndValues[1] == "Sort"
+	}, time.Second, time.Millisecond)
+	_, keysAndValues := logger.entry()
+	assert.Equal(t, []interface{}{"operator", "Sort", "error", "sort"}, keysAndValues[:4])
+}
+
+func TestNewZapLogger(t *testing.T) {
+	core, logs := observer.New(zap.ErrorLevel)
+	NewZapLogger(zap.New(core)).Error("failed", "item", 1)
+	assert.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "failed", entry.Message)
+	assert.Equal(t, map[string]interface{}{"item": int64(1)}, entry.ContextMap())
+}
+
+func TestNewSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	NewSlogLogger(slog.New(slog.NewTextHandler(&buf, nil))).Error("failed", "item", 1)
+	assert.Contains(t, buf.String(), `level=ERROR msg=failed item=1`)
+}
+
+func TestNewNopLogger(t *testing.T) {
+	NewNopLogger().Error("failed", "item", 1)
+}
@@ -18,9 +18,37 @@
 
 package stream
 
+import "time"
+
 // Options defines the struct to customize a Stream.
 type Options struct {
-	workSize int
+	workSize  int
+	errorMode ErrorMode
+	ordered   bool
+	// onPanic handles the panic of an element, the returned error fails the Stream.
+	onPanic    func(item, recovered interface{}) error
+	logger     Logger
+	workerPool *WorkerPool
+	clock      Clock
+	// rate and burst configure limiter, which is shared by all the workers of an operator.
+	rate     float64
+	burst    int
+	limiter  *tokenBucket
+	retry    *RetryPolicy
+	joinMode JoinMode
+	// memoryLimit and tempDir configure SortExternal.
+	memoryLimit int64
+	tempDir     string
+	// insertionOrder, groupIdle and maxGroups configure Group and GroupByStream.
+	insertionOrder bool
+	groupIdle      time.Duration
+	maxGroups      int
+	// backpressure and bufferSize configure Tee and Broadcast.
+	backpressure Backpressure
+	bufferSize   int
+	shardKey     KeyFunc
+	// completeOnTimeout completes the Stream instead of failing it on timeout.
+	completeOnTimeout bool
 }
 
 // Option defines the method to customize a Stream.
@@ -33,9 +61,18 @@ func loadOptions(options ...Option) *Options {
 		option(op)
 	}
 	// set the default pool size
+	if op.workSize <= 0 && op.workerPool != nil {
+		op.workSize = op.workerPool.Size()
+	}
 	if op.workSize <= 0 {
 		op.workSize = 1
 	}
+	if op.clock == nil {
+		op.clock = SystemClock()
+	}
+	if op.rate != 0 || op.burst != 0 {
+		op.limiter = newTokenBucket(op.rate, op.burst, op.clock)
+	}
 	return op
 }
 
@@ -52,3 +89,44 @@ func WithWorkSize(size int) Option {
 		options.workSize = size
 	}
 }
+
+// WithOrdered return a Option that keeps the results in the order of the elements,
+// while the elements are still handled concurrently
+func WithOrdered() Option {
+	return func(options *Options) {
+		options.ordered = true
+	}
+}
+
+// WithLogger return a Option that set the Logger used instead of the one shared by all the Streams
+func WithLogger(logger Logger) Option {
+	return func(options *Options) {
+		options.logger = logger
+	}
+}
+
+// WithWorkerPool return a Option that runs the elements by the workers of pool instead of new goroutines,
+// the size of work is the size of pool by default, and the results of an element are buffered until
+// its worker is released, so that pool can be shared by the chained stages
+func WithWorkerPool(pool *WorkerPool) Option {
+	return func(options *Options) {
+		options.workerPool = pool
+	}
+}
+
+// ErrorMode defines how a Stream handles the errors of its elements.
+type ErrorMode int
+
+const (
+	// FailFast stops the whole Stream on the first error.
+	FailFast ErrorMode = iota
+	// CollectAll keeps the Stream running and collects all the errors.
+	CollectAll
+)
+
+// WithErrorMode return a Option that set the mode to handle errors
+func WithErrorMode(mode ErrorMode) Option {
+	return func(options *Options) {
+		options.errorMode = mode
+	}
+}
@@ -18,3 +18,26 @@ func TestWithWorkSize(t *testing.T) {
 	withWorkSize(ops)
 	assert.Equal(t, &Options{workSize: 1}, ops)
 }
+
+func TestWithErrorMode(t *testing.T) {
+	withErrorMode := WithErrorMode(CollectAll)
+	ops := new(Options)
+	withErrorMode(ops)
+	assert.Equal(t, &Options{errorMode: CollectAll}, ops)
+	assert.Equal(t, FailFast, loadOptions().errorMode)
+}
+
+func TestWithOrdered(t *testing.T) {
+	withOrdered := WithOrdered()
+	ops := new(Options)
+	withOrdered(ops)
+	assert.Equal(t, &Options{ordered: true}, ops)
+}
+
+func TestWithLogger(t *testing.T) {
+	logger := NewNopLogger()
+	withLogger := WithLogger(logger)
+	ops := new(Options)
+	withLogger(ops)
+	assert.Equal(t, &Options{logger: logger}, ops)
+}
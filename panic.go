@@ -0,0 +1,123 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// PanicHandler defines the method to handle the panic recovered when handling an element.
+type PanicHandler func(item, recovered interface{})
+
+// PanicError is the error of an element which panicked.
+type PanicError struct {
+	Item      interface{}
+	Recovered interface{}
+}
+
+// Error returns the message of the PanicError.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("stream: panic on element %v: %v", e.Item, e.Recovered)
+}
+
+// WithPanicHandler return a Option that handles the panics of elements with handler,
+// the elements are dropped
+func WithPanicHandler(handler PanicHandler) Option {
+	return func(options *Options) {
+		options.onPanic = func(item, recovered interface{}) error {
+			handler(item, recovered)
+			return nil
+		}
+	}
+}
+
+// WithRePanic return a Option that panics again with the panics of elements,
+// which crashes the program
+func WithRePanic() Option {
+	return func(options *Options) {
+		options.onPanic = func(item, recovered interface{}) error {
+			panic(recovered)
+		}
+	}
+}
+
+// WithPanicError return a Option that converts the panics of elements to PanicError,
+// which are handled according to the ErrorMode
+func WithPanicError() Option {
+	return func(options *Options) {
+		options.onPanic = func(item, recovered interface{}) error {
+			return &PanicError{Item: item, Recovered: recovered}
+		}
+	}
+}
+
+// WithPanicSkip return a Option that drops the elements which panicked, and counts them by counter
+func WithPanicSkip(counter *int64) Option {
+	return func(options *Options) {
+		options.onPanic = func(item, recovered interface{}) error {
+			atomic.AddInt64(counter, 1)
+			return nil
+		}
+	}
+}
+
+// WithPanicSideOutput return a Option that sends the elements which panicked to side,
+// side must be consumed, or the Stream is blocked
+func WithPanicSideOutput(side chan<- interface{}) Option {
+	return func(options *Options) {
+		options.onPanic = func(item, recovered interface{}) error {
+			side <- item
+			return nil
+		}
+	}
+}
+
+// rePanic is the panic raised again when handling a panic, it is not recovered by the stages.
+type rePanic struct {
+	recovered interface{}
+}
+
+// String returns the message of the panic raised again.
+func (r rePanic) String() string {
+	return fmt.Sprint(r.recovered)
+}
+
+// handlePanic handles the panic recovered when operator handles item, the returned error fails the Stream.
+// The panic is logged if no option such as WithPanicHandler is given, the item is dropped.
+func (o *Options) handlePanic(operator string, item, recovered interface{}) error {
+	if o.onPanic != nil {
+		defer func() {
+			// the panic raised by onPanic such as WithRePanic crashes the program as expected.
+			if r := recover(); r != nil {
+				panic(rePanic{recovered: r})
+			}
+		}()
+		return o.onPanic(item, recovered)
+	}
+
+	logger := o.logger
+	if logger == nil {
+		logger = currentLogger()
+	}
+	logger.Error("stream failed", "operator", operator, "item", item, "error", recovered,
+		"stack", string(debug.Stack()))
+	return nil
+}
@@ -0,0 +1,100 @@
+package stream
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+func panicOnOdd(item interface{}) interface{} {
+	if item.(int)%2 == 1 {
+		panic("odd")
+	}
+	return item
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	var items, recovered []interface{}
+	stream := Of(1, 2, 3, 4).Map(panicOnOdd, WithPanicHandler(func(item, r interface{}) {
+		items = append(items, item)
+		recovered = append(recovered, r)
+	}))
+	equal(t, stream, []interface{}{2, 4})
+	assert.Equal(t, []interface{}{1, 3}, items)
+	assert.Equal(t, []interface{}{"odd", "odd"}, recovered)
+}
+
+func TestWithRePanic(t *testing.T) {
+	ops := loadOptions(WithRePanic())
+	assert.PanicsWithValue(t, "odd", func() {
+		_ = ops.onPanic(1, "odd")
+	})
+}
+
+func TestWithRePanic_Crash(t *testing.T) {
+	// the pipeline is run by a child process, which is expected to crash.
+	if mode := os.Getenv("STREAM_REPANIC_MODE"); mode != "" {
+		opts := []Option{WithRePanic()}
+		if mode == "parallel" {
+			opts = append(opts, WithWorkSize(2))
+		}
+		_ = Of(1, 2, 3, 4).Map(panicOnOdd, opts...).Finish()
+		return
+	}
+
+	for _, mode := range []string{"sequential", "parallel"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestWithRePanic_Crash$")
+		cmd.Env = append(os.Environ(), "STREAM_REPANIC_MODE="+mode)
+		output, err := cmd.CombinedOutput()
+		var exitErr *exec.ExitError
+		assert.True(t, errors.As(err, &exitErr), mode)
+		assert.Contains(t, string(output), "panic: odd", mode)
+	}
+}
+
+func TestWithPanicError(t *testing.T) {
+	err := Of(1, 2, 3).Map(panicOnOdd, WithPanicError()).Finish()
+	var panicError *PanicError
+	assert.True(t, errors.As(err, &panicError))
+	assert.Equal(t, &PanicError{Item: 1, Recovered: "odd"}, panicError)
+	assert.Equal(t, "stream: panic on element 1: odd", err.Error())
+
+	stream := Of(1, 2, 3).Map(panicOnOdd, WithPanicError(), WithErrorMode(CollectAll))
+	equal(t, stream, []interface{}{2})
+	assert.Error(t, stream.Err())
+}
+
+func TestWithPanicSkip(t *testing.T) {
+	var count int64
+	equal(t, Of(1, 2, 3).Map(panicOnOdd, WithPanicSkip(&count), WithWorkSize(2)).Sort(func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}), []interface{}{2})
+	assert.Equal(t, int64(2), count)
+}
+
+func TestWithPanicSideOutput(t *testing.T) {
+	side := make(chan interface{}, 2)
+	stream := Of(1, 2, 3).Map(panicOnOdd, WithPanicSideOutput(side), WithOrdered())
+	equal(t, stream, []interface{}{2})
+	close(side)
+	equal(t, Range(side), []interface{}{1, 3})
+}
+
+func TestOptions_HandlePanic(t *testing.T) {
+	logger := new(recordLogger)
+	stream := Of(1, 2).Map(panicOnOdd, WithLogger(logger))
+	equal(t, stream, []interface{}{2})
+	assert.Equal(t, "stream failed", logger.msg)
+	assert.Equal(t, []interface{}{"operator", "Map", "item", 1, "error", "odd"}, logger.keysAndValues[:6])
+	assert.Equal(t, "stack", logger.keysAndValues[6])
+}
+
+func TestOptions_HandleRePanic(t *testing.T) {
+	// the panic raised again is marked, so that it is not recovered by the stages.
+	assert.PanicsWithValue(t, rePanic{recovered: "odd"}, func() {
+		_ = loadOptions(WithRePanic()).handlePanic("Map", 1, "odd")
+	})
+	assert.Equal(t, "odd", rePanic{recovered: "odd"}.String())
+}
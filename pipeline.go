@@ -0,0 +1,83 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+// A pipeline holds the states shared by all the stages of a Stream.
+type pipeline struct {
+	// parent is the context given by the caller.
+	parent context.Context
+	// ctx is done when parent is done or the pipeline fails fast.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock   sync.Mutex
+	errs   []error
+	failed bool
+}
+
+// newPipeline returns a pipeline bound to ctx.
+func newPipeline(ctx context.Context) *pipeline {
+	p := &pipeline{parent: ctx}
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	return p
+}
+
+// fail records err, the whole pipeline is stopped if failFast is true.
+// The errors occurred after the pipeline failed fast are ignored.
+func (p *pipeline) fail(err error, failFast bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.failed {
+		return
+	}
+	p.errs = append(p.errs, err)
+	if failFast {
+		p.failed = true
+		p.cancel()
+	}
+}
+
+// merge records the errors of other.
+func (p *pipeline) merge(other *pipeline) {
+	if p == other {
+		return
+	}
+	if err := other.err(); err != nil {
+		p.fail(err, false)
+	}
+}
+
+// err returns the errors of the pipeline, or the error of the parent context if it is done.
+func (p *pipeline) err() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if len(p.errs) != 0 {
+		return multierr.Combine(p.errs...)
+	}
+	return p.parent.Err()
+}
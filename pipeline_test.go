@@ -0,0 +1,46 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPipeline_Fail(t *testing.T) {
+	err1, err2 := errors.New("1"), errors.New("2")
+
+	p := newPipeline(context.Background())
+	assert.NoError(t, p.err())
+	p.fail(err1, false)
+	p.fail(err2, false)
+	assert.NoError(t, p.ctx.Err())
+	assert.ErrorIs(t, p.err(), err1)
+	assert.ErrorIs(t, p.err(), err2)
+
+	p = newPipeline(context.Background())
+	p.fail(err1, true)
+	p.fail(err2, true)
+	assert.Error(t, p.ctx.Err())
+	assert.Equal(t, err1, p.err())
+}
+
+func TestPipeline_Merge(t *testing.T) {
+	err := errors.New("error")
+	p := newPipeline(context.Background())
+	other := newPipeline(context.Background())
+	p.merge(other)
+	assert.NoError(t, p.err())
+	other.fail(err, false)
+	p.merge(other)
+	assert.Equal(t, err, p.err())
+	p.merge(p)
+	assert.Equal(t, err, p.err())
+}
+
+func TestPipeline_Err(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := newPipeline(ctx)
+	cancel()
+	assert.Equal(t, context.Canceled, p.err())
+}
@@ -0,0 +1,109 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrWorkerPoolClosed is returned when a task is submitted to a closed WorkerPool.
+var ErrWorkerPoolClosed = errors.New("stream: worker pool closed")
+
+// A WorkerPool runs tasks with a fixed number of long-lived workers,
+// it can be shared by many Streams to bound the total concurrency.
+type WorkerPool struct {
+	size  int
+	tasks chan func()
+	done  chan struct{}
+	once  sync.Once
+	wg    sync.WaitGroup
+}
+
+// NewWorkerPool returns a WorkerPool with n workers.
+func NewWorkerPool(n int) *WorkerPool {
+	if n < 1 {
+		panic("n should be greater than 0")
+	}
+
+	p := &WorkerPool{
+		size:  n,
+		tasks: make(chan func()),
+		done:  make(chan struct{}),
+	}
+	p.wg.Add(n)
+	for i := 0; i < n; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Size returns the number of workers.
+func (p *WorkerPool) Size() int {
+	return p.size
+}
+
+// Submit blocks until a worker runs task, or returns an error if ctx is done or the WorkerPool is closed.
+// Like a goroutine, a panic in task crashes the program.
+func (p *WorkerPool) Submit(ctx context.Context, task func()) error {
+	select {
+	case <-p.done:
+		return ErrWorkerPoolClosed
+	default:
+	}
+
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-p.done:
+		return ErrWorkerPoolClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the WorkerPool from accepting tasks, and waits for the running tasks to finish.
+func (p *WorkerPool) Close() {
+	p.once.Do(func() {
+		close(p.done)
+	})
+	p.wg.Wait()
+}
+
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case task := <-p.tasks:
+			task()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// run runs task by the WorkerPool given by the option WithWorkerPool, or by a new goroutine.
+func (o *Options) run(ctx context.Context, task func()) error {
+	if o.workerPool == nil {
+		go task()
+		return nil
+	}
+	return o.workerPool.Submit(ctx, task)
+}
@@ -0,0 +1,123 @@
+package stream
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewWorkerPool(t *testing.T) {
+	assert.Panics(t, func() {
+		NewWorkerPool(0)
+	})
+	pool := NewWorkerPool(2)
+	defer pool.Close()
+	assert.Equal(t, 2, pool.Size())
+}
+
+func TestWorkerPool_Submit(t *testing.T) {
+	pool := NewWorkerPool(1)
+	block := make(chan struct{})
+	assert.NoError(t, pool.Submit(context.Background(), func() {
+		<-block
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, pool.Submit(ctx, func() {}))
+
+	close(block)
+	pool.Close()
+	pool.Close()
+	assert.Equal(t, ErrWorkerPoolClosed, pool.Submit(context.Background(), func() {}))
+}
+
+func TestWorkerPool_Close(t *testing.T) {
+	pool := NewWorkerPool(2)
+	var finished int32
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, pool.Submit(context.Background(), func() {
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&finished, 1)
+		}))
+	}
+	pool.Close()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&finished))
+}
+
+func TestWithWorkerPool(t *testing.T) {
+	pool := NewWorkerPool(4)
+	defer pool.Close()
+	assert.Equal(t, 4, loadOptions(WithWorkerPool(pool)).workSize)
+	assert.Equal(t, 2, loadOptions(WithWorkerPool(pool), WithWorkSize(2)).workSize)
+
+	var running, maxRunning int32
+	track := func(item interface{}) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+
+	// the pool is shared by the streams, so the total concurrency is bounded.
+	streams := make([]*Stream, 4)
+	for i := range streams {
+		streams[i] = From(func(source chan<- interface{}) {
+			for i := 0; i < 50; i++ {
+				source <- i
+			}
+		}).Map(func(item interface{}) interface{} {
+			track(item)
+			return item
+		}, WithWorkerPool(pool)).Filter(func(item interface{}) bool {
+			track(item)
+			return true
+		}, WithWorkerPool(pool))
+	}
+	assertEqual(t, 200, Concat(streams[0], streams[1:]...).Count())
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(4))
+
+	equal(t, Of(1, 2, 3).FlatMap(func(item interface{}) interface{} {
+		return item
+	}, WithWorkerPool(pool), WithOrdered()), []interface{}{1, 2, 3})
+	assert.NoError(t, Of(1, 2, 3).ParallelFinish(func(item interface{}) {}, WithWorkerPool(pool)))
+
+	closed := NewWorkerPool(1)
+	closed.Close()
+	assert.Equal(t, ErrWorkerPoolClosed, Of(1).Map(func(item interface{}) interface{} {
+		return item
+	}, WithWorkerPool(closed)).Finish())
+	assert.Equal(t, ErrWorkerPoolClosed, Of(1).Map(func(item interface{}) interface{} {
+		return item
+	}, WithWorkerPool(closed), WithOrdered()).Finish())
+}
+
+func TestWithWorkerPool_Chained(t *testing.T) {
+	identity := func(item interface{}) interface{} {
+		return item
+	}
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = i
+	}
+
+	pool := NewWorkerPool(2)
+	defer pool.Close()
+	assertEqual(t, 100, Of(items...).Map(identity, WithWorkerPool(pool)).Map(identity, WithWorkerPool(pool)).Count())
+	assertEqual(t, 100, Of(items...).Map(identity, WithWorkerPool(pool), WithOrdered()).
+		Map(identity, WithWorkerPool(pool), WithOrdered()).Count())
+
+	single := NewWorkerPool(1)
+	defer single.Close()
+	assertEqual(t, 200, Of(items...).Walk(func(item interface{}, pipe chan<- interface{}) {
+		pipe <- item
+		pipe <- item
+	}, WithWorkerPool(single)).FlatMap(identity, WithWorkerPool(single)).Map(identity, WithWorkerPool(single)).Count())
+}
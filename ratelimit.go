@@ -0,0 +1,126 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A tokenBucket limits the rate of the elements, a token is taken for each element,
+// and the tokens are put at rate per second up to burst.
+type tokenBucket struct {
+	clock Clock
+	rate  float64
+	burst float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full tokenBucket.
+func newTokenBucket(rate float64, burst int, clock Clock) *tokenBucket {
+	if rate <= 0 {
+		panic("rate should be greater than 0")
+	}
+	if burst < 1 {
+		panic("burst should be greater than 0")
+	}
+	return &tokenBucket{
+		clock:  clock,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// wait blocks until a token is taken, or returns the error of ctx if ctx is done first.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	now := b.clock.Now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	// the token is reserved, the caller waits until it is put if it is not there yet.
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.lock.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := b.clock.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		// give back the token reserved.
+		b.lock.Lock()
+		b.tokens++
+		b.lock.Unlock()
+		return ctx.Err()
+	}
+}
+
+// WithRateLimit return a Option that limits the elements handled to rate per second with bursts of up to burst,
+// the limit is shared by all the workers
+func WithRateLimit(rate float64, burst int) Option {
+	return func(options *Options) {
+		options.rate = rate
+		options.burst = burst
+	}
+}
+
+// wait blocks until the element is allowed by the rate limit, or returns the error of ctx if ctx is done first.
+func (o *Options) wait(ctx context.Context) error {
+	if o.limiter == nil {
+		return nil
+	}
+	return o.limiter.wait(ctx)
+}
+
+// RateLimit Returns a Stream that emits the elements at rate per second with bursts of up to burst.
+func (s *Stream) RateLimit(rate float64, burst int, opts ...Option) *Stream {
+	option := loadOptions(opts...)
+	limiter := newTokenBucket(rate, burst, option.clock)
+	return s.stage("RateLimit", 0, func(ctx context.Context, source chan interface{}) {
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				return
+			}
+			if limiter.wait(ctx) != nil {
+				return
+			}
+			source <- item
+		}
+	})
+}
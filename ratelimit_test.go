@@ -0,0 +1,98 @@
+package stream
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	clock := newFakeClock()
+	bucket := newTokenBucket(1, 2, clock)
+	assert.NoError(t, bucket.wait(context.Background()))
+	assert.NoError(t, bucket.wait(context.Background()))
+
+	waited := make(chan error)
+	go func() {
+		waited <- bucket.wait(context.Background())
+	}()
+	clock.waitTimers(1)
+	clock.Advance(time.Second)
+	assert.NoError(t, <-waited)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		waited <- bucket.wait(ctx)
+	}()
+	clock.waitTimers(2)
+	cancel()
+	assert.Equal(t, context.Canceled, <-waited)
+	assert.Equal(t, context.Canceled, bucket.wait(ctx))
+
+	// the token given back is put again after a second.
+	clock.Advance(time.Second)
+	assert.NoError(t, bucket.wait(context.Background()))
+
+	assert.Panics(t, func() {
+		newTokenBucket(0, 1, clock)
+	})
+	assert.Panics(t, func() {
+		newTokenBucket(1, 0, clock)
+	})
+}
+
+func TestWithRateLimit(t *testing.T) {
+	withRateLimit := WithRateLimit(10, 2)
+	ops := new(Options)
+	withRateLimit(ops)
+	assert.Equal(t, &Options{rate: 10, burst: 2}, ops)
+	assert.Nil(t, loadOptions().limiter)
+	assert.NotNil(t, loadOptions(withRateLimit).limiter)
+}
+
+func TestStream_WithRateLimit(t *testing.T) {
+	identity := func(item interface{}) interface{} {
+		return item
+	}
+	for _, opts := range [][]Option{
+		nil,
+		{WithWorkSize(2)},
+		{WithWorkSize(2), WithOrdered()},
+	} {
+		clock := newFakeClock()
+		opts = append(opts, WithClock(clock), WithRateLimit(1, 1))
+		source := Of(1, 2, 3).Map(identity, opts...).Chan()
+
+		items := []interface{}{<-source}
+		clock.waitTimers(1)
+		clock.Advance(time.Second)
+		items = append(items, <-source)
+		clock.waitTimers(2)
+		clock.Advance(time.Second)
+		items = append(items, <-source)
+		assert.ElementsMatch(t, []interface{}{1, 2, 3}, items)
+		_, ok := <-source
+		assert.False(t, ok)
+	}
+}
+
+func TestStream_RateLimit(t *testing.T) {
+	clock := newFakeClock()
+	source := Of(1, 2, 3).RateLimit(1, 2, WithClock(clock)).Chan()
+	assert.Equal(t, 1, <-source)
+	assert.Equal(t, 2, <-source)
+	clock.waitTimers(1)
+	clock.Advance(time.Second)
+	assert.Equal(t, 3, <-source)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	stream, exited := infinite()
+	equal(t, stream.RateLimit(1, 1, WithClock(newFakeClock())).Limit(1), []interface{}{0})
+	<-exited
+
+	assert.Panics(t, func() {
+		Of(1).RateLimit(0, 1)
+	})
+}
@@ -0,0 +1,165 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ErrAttemptTimeout is the error of an attempt which did not finish within the AttemptTimeout of a RetryPolicy.
+var ErrAttemptTimeout = errors.New("stream: attempt timeout")
+
+// A RetryPolicy defines how to retry the functions which failed to handle elements.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts including the first one.
+	MaxAttempts int
+	// InitialBackoff is the time to wait before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the time to wait before a retry, no cap if it is 0.
+	MaxBackoff time.Duration
+	// Multiplier multiplies the backoff after each retry, it is 2 if it is 0.
+	Multiplier float64
+	// Jitter randomly reduces each backoff by up to the fraction, it should be in [0, 1].
+	Jitter float64
+	// Retryable reports whether an error should be retried, all the errors are retried if it is nil.
+	Retryable func(err error) bool
+	// AttemptTimeout fails an attempt with ErrAttemptTimeout if it does not finish in time, no timeout if it is 0.
+	// The ctx of the attempt timed out is canceled, and it is waited for before the next attempt,
+	// its results are dropped. Use the functions receiving ctx, such as MapErrContext, to return in time.
+	AttemptTimeout time.Duration
+}
+
+// WithRetry return a Option that retries the functions of FilterErr, MapErr and WalkErr, and their variants
+// receiving ctx such as MapErrContext by policy when they fail,
+// only the results of the successful attempt are sent, and the error of the last attempt is handled according
+// to the ErrorMode
+func WithRetry(policy RetryPolicy) Option {
+	if policy.MaxAttempts < 1 {
+		panic("MaxAttempts should be greater than 0")
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		panic("Jitter should be in [0, 1]")
+	}
+	if policy.Multiplier == 0 {
+		policy.Multiplier = 2
+	}
+	return func(options *Options) {
+		options.retry = &policy
+	}
+}
+
+// backoff returns the time to wait before the retry after the given attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	backoff -= backoff * p.Jitter * rand.Float64()
+	return time.Duration(backoff)
+}
+
+// retryable reports whether err should be retried.
+func (p *RetryPolicy) retryable(err error) bool {
+	return p.Retryable == nil || p.Retryable(err)
+}
+
+// attempt calls f with the results sent by emit, f is retried according to the RetryPolicy given by the option
+// WithRetry, and only the results of the successful attempt are sent.
+// It returns nil if ctx is done before f succeeds or gives up.
+func (o *Options) attempt(ctx context.Context, f applyFunc, item interface{}, emit func(interface{})) error {
+	policy := o.retry
+	if policy == nil {
+		return f(ctx, item, emit)
+	}
+
+	for attempt := 1; ; attempt++ {
+		results, err := o.attemptOnce(ctx, f, item)
+		if err == nil {
+			for _, result := range results {
+				emit(result)
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return err
+		}
+
+		timer := o.clock.NewTimer(policy.backoff(attempt))
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		}
+	}
+}
+
+// attemptOnce calls f once within the AttemptTimeout, the panic of f is raised again in the caller.
+// The attempt is waited for even if it is timed out or ctx is done, so that it never overlaps the next one.
+func (o *Options) attemptOnce(ctx context.Context, f applyFunc, item interface{}) ([]interface{}, error) {
+	if o.retry.AttemptTimeout <= 0 {
+		var results []interface{}
+		err := f(ctx, item, func(v interface{}) {
+			results = append(results, v)
+		})
+		return results, err
+	}
+
+	var (
+		results   []interface{}
+		err       error
+		recovered interface{}
+	)
+	attemptCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			recovered = recover()
+		}()
+		err = f(attemptCtx, item, func(v interface{}) {
+			results = append(results, v)
+		})
+	}()
+
+	timer := o.clock.NewTimer(o.retry.AttemptTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		if recovered != nil {
+			panic(recovered)
+		}
+		return results, err
+	case <-timer.C():
+		cancel()
+		<-done
+		return nil, ErrAttemptTimeout
+	case <-ctx.Done():
+		<-done
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,166 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithRetry(t *testing.T) {
+	withRetry := WithRetry(RetryPolicy{MaxAttempts: 3})
+	ops := new(Options)
+	withRetry(ops)
+	assert.Equal(t, &Options{retry: &RetryPolicy{MaxAttempts: 3, Multiplier: 2}}, ops)
+
+	assert.Panics(t, func() {
+		WithRetry(RetryPolicy{})
+	})
+	assert.Panics(t, func() {
+		WithRetry(RetryPolicy{MaxAttempts: 1, Jitter: 2})
+	})
+}
+
+func TestRetryPolicy_backoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	assert.Equal(t, time.Second, policy.backoff(1))
+	assert.Equal(t, 2*time.Second, policy.backoff(2))
+	assert.Equal(t, 4*time.Second, policy.backoff(3))
+	assert.Equal(t, 5*time.Second, policy.backoff(4))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		backoff := policy.backoff(1)
+		assert.True(t, backoff >= time.Second/2 && backoff <= time.Second)
+	}
+}
+
+func TestStream_Retry(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	// flaky fails the first failures attempts of each element.
+	flaky := func(failures int32, attempts *int32) MapErrFunc {
+		return func(item interface{}) (interface{}, error) {
+			if atomic.AddInt32(attempts, 1) <= failures {
+				return nil, errFlaky
+			}
+			return item, nil
+		}
+	}
+
+	var attempts int32
+	stream := Of(1).MapErr(flaky(2, &attempts), WithRetry(RetryPolicy{MaxAttempts: 3}))
+	equal(t, stream, []interface{}{1})
+	assert.NoError(t, stream.Err())
+	assert.Equal(t, int32(3), attempts)
+
+	attempts = 0
+	stream = Of(1).MapErr(flaky(3, &attempts), WithRetry(RetryPolicy{MaxAttempts: 3}))
+	equal(t, stream, []interface{}{})
+	assert.Equal(t, errFlaky, stream.Err())
+	assert.Equal(t, int32(3), attempts)
+
+	attempts = 0
+	stream = Of(1).MapErr(flaky(1, &attempts), WithRetry(RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool {
+		return !errors.Is(err, errFlaky)
+	}}))
+	equal(t, stream, []interface{}{})
+	assert.Equal(t, errFlaky, stream.Err())
+	assert.Equal(t, int32(1), attempts)
+
+	attempts = 0
+	stream = Of(1, 2, 3).MapErr(flaky(1, &attempts), WithRetry(RetryPolicy{MaxAttempts: 2}), WithWorkSize(3))
+	result, err := stream.Collect(ToSlice())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []interface{}{1, 2, 3}, result)
+}
+
+func TestStream_RetryWalkErr(t *testing.T) {
+	var attempts int32
+	stream := Of(1, 2).WalkErr(func(item interface{}, pipe chan<- interface{}) error {
+		pipe <- item
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return errors.New("flaky")
+		}
+		pipe <- item
+		return nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 2}), WithWorkSize(2))
+	result, err := stream.Collect(ToSlice())
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []interface{}{1, 1, 2, 2}, result)
+}
+
+func TestStream_RetryBackoff(t *testing.T) {
+	clock := newFakeClock()
+	var attempts int32
+	source := Of(1).FilterErr(func(item interface{}) (bool, error) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			return false, errors.New("flaky")
+		}
+		return true, nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Second}), WithClock(clock)).Chan()
+
+	clock.waitTimers(1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, <-source)
+}
+
+func TestStream_RetryAttemptTimeout(t *testing.T) {
+	clock := newFakeClock()
+	var running, attempts int32
+	source := Of(1).MapErrContext(func(ctx context.Context, item interface{}) (interface{}, error) {
+		// the attempts never overlap.
+		assert.Equal(t, int32(1), atomic.AddInt32(&running, 1))
+		defer atomic.AddInt32(&running, -1)
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return item, nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, AttemptTimeout: time.Second}), WithClock(clock)).Chan()
+
+	clock.waitTimers(1)
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, <-source)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	// the attempt timed out is waited for before the next attempt.
+	clock = newFakeClock()
+	release := make(chan struct{})
+	attempts = 0
+	source = Of(1).MapErr(func(item interface{}) (interface{}, error) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-release
+		}
+		return item, nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, AttemptTimeout: time.Second}), WithClock(clock)).Chan()
+
+	clock.waitTimers(1)
+	clock.Advance(time.Second)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	close(release)
+	assert.Equal(t, 1, <-source)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	clock = newFakeClock()
+	stream := Of(1).MapErrContext(func(ctx context.Context, item interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return item, nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 1, AttemptTimeout: time.Second}), WithClock(clock))
+	source = stream.Chan()
+	clock.waitTimers(1)
+	clock.Advance(time.Second)
+	_, ok = <-source
+	assert.False(t, ok)
+	assert.Equal(t, ErrAttemptTimeout, stream.Err())
+
+	stream = Of(1).MapErr(func(item interface{}) (interface{}, error) {
+		panic("panic")
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, AttemptTimeout: time.Second}), WithPanicError())
+	equal(t, stream, []interface{}{})
+	assert.Equal(t, &PanicError{Item: 1, Recovered: "panic"}, stream.Err())
+}
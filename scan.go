@@ -0,0 +1,75 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import "context"
+
+// A KeyedValue is a value of a key.
+type KeyedValue struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// Scan Returns a Stream of the accumulators, each element is accumulated into the accumulator by fn,
+// and the accumulator is emitted after each element. The first accumulator is seed.
+func (s *Stream) Scan(seed interface{}, fn AccumulateFunc) *Stream {
+	return s.applyState("Scan", func() applyFunc {
+		acc := seed
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
+			acc = fn(acc, item)
+			emit(acc)
+			return nil
+		}
+	})
+}
+
+// ScanWithIndex Returns a Stream like Scan, the index of each element is passed to fn, which starts from 0.
+func (s *Stream) ScanWithIndex(seed interface{}, fn func(acc, item interface{}, index int) interface{}) *Stream {
+	return s.applyState("ScanWithIndex", func() applyFunc {
+		acc := seed
+		index := 0
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
+			acc = fn(acc, item, index)
+			index++
+			emit(acc)
+			return nil
+		}
+	})
+}
+
+// ScanByKey Returns a Stream of KeyedValue like Scan, but there is an accumulator of each key.
+// Each element is accumulated into the accumulator of its key, then the key and the accumulator are emitted.
+// The first accumulator of each key is seed, so fn should not modify the accumulator in place if seed is
+// a reference such as a map or a slice.
+func (s *Stream) ScanByKey(key KeyFunc, seed interface{}, fn AccumulateFunc) *Stream {
+	return s.applyState("ScanByKey", func() applyFunc {
+		accs := make(map[interface{}]interface{})
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
+			k := key(item)
+			acc, ok := accs[k]
+			if !ok {
+				acc = seed
+			}
+			acc = fn(acc, item)
+			accs[k] = acc
+			emit(KeyedValue{Key: k, Value: acc})
+			return nil
+		}
+	})
+}
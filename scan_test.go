@@ -0,0 +1,42 @@
+package stream
+
+import "testing"
+
+func TestStream_Scan(t *testing.T) {
+	sum := func(acc, item interface{}) interface{} {
+		return acc.(int) + item.(int)
+	}
+	equal(t, Of(1, 2, 3, 4).Scan(0, sum), []interface{}{1, 3, 6, 10})
+	equal(t, Empty().Scan(0, sum), []interface{}{})
+
+	// the cumulative distinct counts.
+	equal(t, Of("a", "b", "a", "c").Scan(map[interface{}]struct{}{}, func(acc, item interface{}) interface{} {
+		acc.(map[interface{}]struct{})[item] = struct{}{}
+		return acc
+	}).Map(func(item interface{}) interface{} {
+		return len(item.(map[interface{}]struct{}))
+	}), []interface{}{1, 2, 2, 3})
+}
+
+func TestStream_ScanWithIndex(t *testing.T) {
+	equal(t, Of("a", "b", "c").ScanWithIndex("", func(acc, item interface{}, index int) interface{} {
+		if index == 0 {
+			return item
+		}
+		return acc.(string) + "," + item.(string)
+	}), []interface{}{"a", "a,b", "a,b,c"})
+}
+
+func TestStream_ScanByKey(t *testing.T) {
+	equal(t, Of(1, 2, 3, 4, 5).ScanByKey(func(item interface{}) interface{} {
+		return item.(int) % 2
+	}, 0, func(acc, item interface{}) interface{} {
+		return acc.(int) + item.(int)
+	}), []interface{}{
+		KeyedValue{Key: 1, Value: 1},
+		KeyedValue{Key: 0, Value: 2},
+		KeyedValue{Key: 1, Value: 4},
+		KeyedValue{Key: 0, Value: 6},
+		KeyedValue{Key: 1, Value: 9},
+	})
+}
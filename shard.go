@@ -0,0 +1,87 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"sync"
+)
+
+// WithShardKey return a Option that walks the elements by the serial workers of the size of work,
+// the elements of the same key are always walked by the same worker in order,
+// the workers are not run by the WorkerPool, and WithOrdered takes precedence over it
+func WithShardKey(key KeyFunc) Option {
+	return func(options *Options) {
+		options.shardKey = key
+	}
+}
+
+// Shard Returns a Stream like Walk, the elements are walked by n serial workers,
+// the elements of the same key are walked by the same worker in order, see WithShardKey.
+func (s *Stream) Shard(key KeyFunc, n int, fn WalkFunc, opts ...Option) *Stream {
+	if n < 1 {
+		panic("n should be greater than 0")
+	}
+	opts = append(opts[:len(opts):len(opts)], WithShardKey(key), WithWorkSize(n))
+	f, release := applyWalk(func(ctx context.Context, item interface{}, pipe chan<- interface{}) error {
+		fn(item, pipe)
+		return nil
+	})
+	return s.walk("Shard", loadOptions(opts...), f, release)
+}
+
+// walkSharded walks through the elements of s with the serial workers given by option,
+// each element is walked by the worker chosen by the hash of its key.
+func (s *Stream) walkSharded(ctx context.Context, walk func(ctx context.Context, item interface{}, emit func(interface{})),
+	pipe chan<- interface{}, option *Options) {
+	var wg sync.WaitGroup
+	emit := func(v interface{}) {
+		pipe <- v
+	}
+	shards := make([]chan interface{}, option.workSize)
+	for i := range shards {
+		shards[i] = make(chan interface{})
+		wg.Add(1)
+		go func(shard <-chan interface{}) {
+			defer wg.Done()
+			for item := range shard {
+				walk(ctx, item, emit)
+			}
+		}(shards[i])
+	}
+	defer func() {
+		for _, shard := range shards {
+			close(shard)
+		}
+		wg.Wait()
+	}()
+
+	for {
+		item, ok := receive(ctx, s.source)
+		if !ok || option.wait(ctx) != nil {
+			return
+		}
+		shard := shards[hashKey(option.shardKey(item))%uint64(len(shards))]
+		select {
+		case shard <- item:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
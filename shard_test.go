@@ -0,0 +1,77 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+type ledgerEntry struct {
+	account int
+	seq     int
+}
+
+// ledger Returns the entries of the accounts, the entries of an account are in order.
+func ledger(accounts, entries int) []interface{} {
+	var items []interface{}
+	next := make([]int, accounts)
+	for len(items) < accounts*entries {
+		account := rand.Intn(accounts)
+		if next[account] == entries {
+			continue
+		}
+		items = append(items, ledgerEntry{account: account, seq: next[account]})
+		next[account]++
+	}
+	return items
+}
+
+// assertLedger asserts that the entries of each account are in order.
+func assertLedger(t *testing.T, s *Stream, accounts, entries int) {
+	next := make([]int, accounts)
+	for item := range s.Chan() {
+		entry := item.(ledgerEntry)
+		assert.Equal(t, next[entry.account], entry.seq)
+		next[entry.account]++
+	}
+	for _, n := range next {
+		assert.Equal(t, entries, n)
+	}
+}
+
+func ledgerKey(item interface{}) interface{} {
+	return item.(ledgerEntry).account
+}
+
+func TestStream_Shard(t *testing.T) {
+	stream := Of(ledger(10, 50)...).Shard(ledgerKey, 4, func(item interface{}, pipe chan<- interface{}) {
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+		pipe <- item
+	})
+	assertLedger(t, stream, 10, 50)
+
+	assert.Panics(t, func() {
+		Of(1).Shard(ledgerKey, 0, func(item interface{}, pipe chan<- interface{}) {})
+	})
+}
+
+func TestWithShardKey(t *testing.T) {
+	assert.NotNil(t, loadOptions(WithShardKey(ledgerKey)).shardKey)
+
+	stream := Of(ledger(10, 50)...).Map(func(item interface{}) interface{} {
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+		return item
+	}, WithShardKey(ledgerKey), WithWorkSize(4))
+	assertLedger(t, stream, 10, 50)
+
+	stream, exited := infinite()
+	equal(t, stream.Map(func(item interface{}) interface{} {
+		return item
+	}, WithShardKey(func(item interface{}) interface{} {
+		return item.(int) % 2
+	}), WithWorkSize(2)).Filter(func(item interface{}) bool {
+		return item.(int)%2 == 0
+	}).Limit(2), []interface{}{0, 2})
+	<-exited
+}
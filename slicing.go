@@ -0,0 +1,104 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import "context"
+
+// TakeWhile Returns a Stream that contains the elements until fn returns false for an element,
+// the element and the rest are dropped. The upstream is stopped once fn returns false.
+func (s *Stream) TakeWhile(fn FilterFunc) *Stream {
+	return s.stage("TakeWhile", 0, func(ctx context.Context, source chan interface{}) {
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok || !fn(item) {
+				return
+			}
+			source <- item
+		}
+	})
+}
+
+// DropWhile Returns a Stream that drops the elements until fn returns false for an element,
+// the element and the rest are kept.
+func (s *Stream) DropWhile(fn FilterFunc) *Stream {
+	return s.applyState("DropWhile", func() applyFunc {
+		dropping := true
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
+			if dropping && fn(item) {
+				return nil
+			}
+			dropping = false
+			emit(item)
+			return nil
+		}
+	})
+}
+
+// TakeUntil Returns a Stream that contains the elements until signal emits an element.
+// The upstream and signal are stopped once signal emits. All the elements are kept if signal ends without emitting.
+func (s *Stream) TakeUntil(signal *Stream) *Stream {
+	return newStage(s.pipeline, "TakeUntil", 0, func(ctx context.Context, source chan interface{}) {
+		signalled := signal.source
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					return
+				}
+				source <- item
+			case _, ok := <-signalled:
+				if ok {
+					return
+				}
+				// signal ends without emitting.
+				signalled = nil
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, s, signal)
+}
+
+// SkipUntil Returns a Stream that drops the elements until signal emits an element.
+// The signal is stopped once it emits. All the elements are dropped if signal ends without emitting.
+func (s *Stream) SkipUntil(signal *Stream) *Stream {
+	return newStage(s.pipeline, "SkipUntil", 0, func(ctx context.Context, source chan interface{}) {
+		signalled := signal.source
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					return
+				}
+				if signalled == nil {
+					source <- item
+				}
+			case _, ok := <-signalled:
+				if !ok {
+					// signal ends without emitting, the rest are dropped.
+					return
+				}
+				signal.cancel()
+				signalled = nil
+			case <-ctx.Done():
+				return
+			}
+		}
+	}, s, signal)
+}
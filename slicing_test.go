@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream_TakeWhile(t *testing.T) {
+	less := func(n int) FilterFunc {
+		return func(item interface{}) bool {
+			return item.(int) < n
+		}
+	}
+	equal(t, Of(1, 2, 3, 1).TakeWhile(less(3)), []interface{}{1, 2})
+	equal(t, Of(1, 2, 3).TakeWhile(less(5)), []interface{}{1, 2, 3})
+	equal(t, Of(1, 2, 3).TakeWhile(less(0)), []interface{}{})
+	equal(t, Empty().TakeWhile(less(0)), []interface{}{})
+
+	stream, exited := infinite()
+	equal(t, stream.TakeWhile(less(3)), []interface{}{0, 1, 2})
+	<-exited
+}
+
+func TestStream_DropWhile(t *testing.T) {
+	less := func(n int) FilterFunc {
+		return func(item interface{}) bool {
+			return item.(int) < n
+		}
+	}
+	equal(t, Of(1, 2, 3, 1).DropWhile(less(3)), []interface{}{3, 1})
+	equal(t, Of(1, 2, 3).DropWhile(less(5)), []interface{}{})
+	equal(t, Of(1, 2, 3).DropWhile(less(0)), []interface{}{1, 2, 3})
+
+	// the log lines from the start marker to the end marker.
+	lines := Of("a", "start", "b", "c", "end", "d")
+	equal(t, lines.DropWhile(func(item interface{}) bool {
+		return item != "start"
+	}).Skip(1).TakeWhile(func(item interface{}) bool {
+		return item != "end"
+	}), []interface{}{"b", "c"})
+}
+
+func TestStream_TakeUntil(t *testing.T) {
+	ch := make(chan interface{})
+	signal := make(chan interface{})
+	source := Range(ch).TakeUntil(Range(signal)).Chan()
+	ch <- 1
+	assert.Equal(t, 1, <-source)
+	ch <- 2
+	assert.Equal(t, 2, <-source)
+	signal <- struct{}{}
+	_, ok := <-source
+	assert.False(t, ok)
+
+	equal(t, Of(1, 2, 3).TakeUntil(Empty()), []interface{}{1, 2, 3})
+
+	stream, exited := infinite()
+	signalStream, signalExited := infinite()
+	equal(t, stream.Filter(func(item interface{}) bool {
+		return false
+	}).TakeUntil(signalStream), []interface{}{})
+	<-exited
+	<-signalExited
+}
+
+func TestStream_SkipUntil(t *testing.T) {
+	ch := make(chan interface{})
+	signal := make(chan interface{})
+	source := Range(ch).SkipUntil(Range(signal)).Chan()
+	ch <- 1
+	ch <- 2
+	signal <- struct{}{}
+	ch <- 3
+	assert.Equal(t, 3, <-source)
+	close(ch)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	equal(t, Of(1, 2, 3).SkipUntil(Empty()), []interface{}{})
+
+	stream, exited := infinite()
+	signalStream, signalExited := infinite()
+	result, err := stream.SkipUntil(signalStream).FindFirst()
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	<-exited
+	<-signalExited
+}
@@ -0,0 +1,368 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"io"
+	"os"
+	"sort"
+)
+
+// defaultMemoryLimit is the memory limit of SortExternal if the option WithMemoryLimit is not given.
+const defaultMemoryLimit = 64 << 20
+
+// A Codec encodes the elements into bytes and decodes them back.
+type Codec interface {
+	// Marshal returns the encoding of item.
+	Marshal(item interface{}) ([]byte, error)
+	// Unmarshal returns the element decoded from data.
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// NewGobCodec returns a Codec based on encoding/gob,
+// the types of the elements other than the basic types should be registered by gob.Register.
+func NewGobCodec() Codec {
+	return gobCodec{}
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Marshal(item interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	// encode the pointer to the interface so that the type is encoded too.
+	if err := gob.NewEncoder(&buf).Encode(&item); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte) (interface{}, error) {
+	var item interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// WithMemoryLimit return a Option that limits the memory used by SortExternal to about bytes,
+// which is estimated by twice the encoded size of the elements, since they are held with their encodings
+func WithMemoryLimit(bytes int64) Option {
+	return func(options *Options) {
+		options.memoryLimit = bytes
+	}
+}
+
+// WithTempDir return a Option that set the directory of the temporary files of SortExternal,
+// the default directory for temporary files is used if it is not given
+func WithTempDir(dir string) Option {
+	return func(options *Options) {
+		options.tempDir = dir
+	}
+}
+
+// SortExternal Returns a Stream sorted by less like Sort, but the memory used is limited by the option
+// WithMemoryLimit. The elements are sorted in runs which fit in the memory, the runs are encoded by codec
+// and spilled to the temporary files, then merged as the Stream is consumed, the runs are merged in many passes
+// if there are too many of them, so that only a few temporary files are open at a time.
+// The order of the equal elements is kept, and the temporary files are removed once the Stream ends or is stopped.
+func (s *Stream) SortExternal(less LessFunc, codec Codec, opts ...Option) *Stream {
+	option := loadOptions(opts...)
+	limit := option.memoryLimit
+	if limit <= 0 {
+		limit = defaultMemoryLimit
+	}
+	return s.stage("SortExternal", 0, func(ctx context.Context, source chan interface{}) {
+		sorter := &externalSorter{less: less, codec: codec, dir: option.tempDir, limit: limit}
+		defer sorter.close()
+		if err := sorter.sort(ctx, s.source, source); err != nil {
+			s.pipeline.fail(err, true)
+		}
+	})
+}
+
+// maxMergeFanIn is the max number of the runs merged at a time, the runs are merged in many passes
+// if there are more, so that the open temporary files are bounded.
+const maxMergeFanIn = 64
+
+// An externalSorter sorts the elements with the temporary files.
+type externalSorter struct {
+	less  LessFunc
+	codec Codec
+	dir   string
+	limit int64
+	// files are the names of the runs spilled, which are closed until they are merged.
+	files []string
+}
+
+// A sortEntry is an element to be sorted with its encoding.
+type sortEntry struct {
+	item interface{}
+	data []byte
+}
+
+// A sortedRun iterates over the sorted elements of a run, ok is false if there are no more elements.
+type sortedRun interface {
+	next() (entry sortEntry, ok bool, err error)
+}
+
+// sort sorts the elements from upstream, and sends them into source.
+func (e *externalSorter) sort(ctx context.Context, upstream <-chan interface{}, source chan<- interface{}) error {
+	var (
+		entries []sortEntry
+		size    int64
+	)
+	for {
+		item, ok := receive(ctx, upstream)
+		if !ok {
+			break
+		}
+		data, err := e.codec.Marshal(item)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, sortEntry{item: item, data: data})
+		// an entry holds both the element and its encoding, the element is estimated by its encoded size.
+		size += 2 * int64(len(data))
+		if size >= e.limit {
+			if err = e.spill(entries); err != nil {
+				return err
+			}
+			entries, size = nil, 0
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	// the last run is not spilled, it is merged in memory after the runs spilled before.
+	e.sortEntries(entries)
+	items := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		items[i] = entry.item
+	}
+	entries = nil
+
+	for len(e.files) > maxMergeFanIn {
+		if err := e.mergePass(ctx); err != nil || ctx.Err() != nil {
+			return err
+		}
+	}
+	runs, err := e.open(e.files)
+	defer closeRuns(runs)
+	if err != nil {
+		return err
+	}
+	return e.merge(ctx, append(runs, &memoryRun{items: items}), func(entry sortEntry) error {
+		source <- entry.item
+		return nil
+	})
+}
+
+// sortEntries sorts entries stably.
+func (e *externalSorter) sortEntries(entries []sortEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return e.less(entries[i].item, entries[j].item)
+	})
+}
+
+// spill sorts entries and writes them into a temporary file.
+func (e *externalSorter) spill(entries []sortEntry) error {
+	e.sortEntries(entries)
+	return e.write(func(write func(entry sortEntry) error) error {
+		for _, entry := range entries {
+			if err := write(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// write writes the entries given by f into a new temporary file, which is closed once they are written.
+// Each entry is written as its encoding prefixed with the length.
+func (e *externalSorter) write(f func(write func(entry sortEntry) error) error) (err error) {
+	file, err := os.CreateTemp(e.dir, "stream-sort-*")
+	if err != nil {
+		return err
+	}
+	e.files = append(e.files, file.Name())
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	writer := bufio.NewWriter(file)
+	var prefix [binary.MaxVarintLen64]byte
+	err = f(func(entry sortEntry) error {
+		n := binary.PutUvarint(prefix[:], uint64(len(entry.data)))
+		if _, err := writer.Write(prefix[:n]); err != nil {
+			return err
+		}
+		_, err := writer.Write(entry.data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// mergePass merges each maxMergeFanIn adjacent runs spilled into a new one, and removes them.
+// The adjacent runs are merged in order, so that the order of the equal elements is kept.
+func (e *externalSorter) mergePass(ctx context.Context) error {
+	files := e.files
+	e.files = nil
+	for i := 0; i < len(files); i += maxMergeFanIn {
+		group := files[i:min(i+maxMergeFanIn, len(files))]
+		runs, err := e.open(group)
+		if err == nil {
+			err = e.write(func(write func(entry sortEntry) error) error {
+				return e.merge(ctx, runs, write)
+			})
+		}
+		closeRuns(runs)
+		for _, name := range group {
+			_ = os.Remove(name)
+		}
+		if err != nil || ctx.Err() != nil {
+			// the files of the groups left are still removed by close.
+			e.files = append(e.files, files[i+len(group):]...)
+			return err
+		}
+	}
+	return nil
+}
+
+// open opens the runs spilled into files.
+func (e *externalSorter) open(files []string) ([]sortedRun, error) {
+	runs := make([]sortedRun, 0, len(files)+1)
+	for _, name := range files {
+		file, err := os.Open(name)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, &fileRun{file: file, reader: bufio.NewReader(file), codec: e.codec})
+	}
+	return runs, nil
+}
+
+// closeRuns closes the files of runs.
+func closeRuns(runs []sortedRun) {
+	for _, run := range runs {
+		if run, ok := run.(*fileRun); ok {
+			_ = run.file.Close()
+		}
+	}
+}
+
+// merge merges the sorted runs, and passes the entries to emit in order.
+func (e *externalSorter) merge(ctx context.Context, runs []sortedRun, emit func(entry sortEntry) error) error {
+	h := &mergeHeap{less: e.less}
+	entries := make([]sortEntry, len(runs))
+	for i, run := range runs {
+		entry, ok, err := run.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			entries[i] = entry
+			h.heads = append(h.heads, mergeHead{item: entry.item, index: i})
+		}
+	}
+	heap.Init(h)
+
+	for h.Len() != 0 {
+		if ctx.Err() != nil {
+			return nil
+		}
+		index := h.heads[0].index
+		if err := emit(entries[index]); err != nil {
+			return err
+		}
+		entry, ok, err := runs[index].next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			entries[index] = entry
+			h.heads[0].item = entry.item
+			heap.Fix(h, 0)
+		} else {
+			entries[index] = sortEntry{}
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+// close removes the temporary files.
+func (e *externalSorter) close() {
+	for _, name := range e.files {
+		_ = os.Remove(name)
+	}
+	e.files = nil
+}
+
+// A fileRun is a run spilled into a file.
+type fileRun struct {
+	file   *os.File
+	reader *bufio.Reader
+	codec  Codec
+}
+
+func (r *fileRun) next() (sortEntry, bool, error) {
+	n, err := binary.ReadUvarint(r.reader)
+	if err == io.EOF {
+		return sortEntry{}, false, nil
+	}
+	if err != nil {
+		return sortEntry{}, false, err
+	}
+	data := make([]byte, n)
+	if _, err = io.ReadFull(r.reader, data); err != nil {
+		return sortEntry{}, false, err
+	}
+	item, err := r.codec.Unmarshal(data)
+	if err != nil {
+		return sortEntry{}, false, err
+	}
+	return sortEntry{item: item, data: data}, true, nil
+}
+
+// A memoryRun is a run held in memory, the encodings of its elements are dropped.
+type memoryRun struct {
+	items []interface{}
+}
+
+func (r *memoryRun) next() (sortEntry, bool, error) {
+	if len(r.items) == 0 {
+		return sortEntry{}, false, nil
+	}
+	item := r.items[0]
+	r.items[0] = nil
+	r.items = r.items[1:]
+	return sortEntry{item: item}, true, nil
+}
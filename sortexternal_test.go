@@ -0,0 +1,160 @@
+package stream
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+func tempFiles(t *testing.T, dir string) int {
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	return len(entries)
+}
+
+func TestStream_SortExternal(t *testing.T) {
+	less := func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = rand.Intn(100)
+	}
+
+	dir := t.TempDir()
+	result, err := Of(items...).SortExternal(less, NewGobCodec(), WithMemoryLimit(256), WithTempDir(dir)).Collect(ToSlice())
+	assert.NoError(t, err)
+	sort.SliceStable(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+	assert.Equal(t, items, result)
+	assert.Equal(t, 0, tempFiles(t, dir))
+
+	// all the elements are sorted in memory.
+	equal(t, Of(3, 1, 2).SortExternal(less, NewGobCodec(), WithTempDir(dir)), []interface{}{1, 2, 3})
+	assert.Equal(t, 0, tempFiles(t, dir))
+}
+
+type sortItem struct {
+	Key   int
+	Value int
+}
+
+func TestStream_SortExternalStable(t *testing.T) {
+	gob.Register(sortItem{})
+	items := make([]interface{}, 100)
+	for i := range items {
+		items[i] = sortItem{Key: i % 3, Value: i}
+	}
+	less := func(a, b interface{}) bool {
+		return a.(sortItem).Key < b.(sortItem).Key
+	}
+	result, err := Of(items...).SortExternal(less, NewGobCodec(), WithMemoryLimit(256), WithTempDir(t.TempDir())).
+		Collect(ToSlice())
+	assert.NoError(t, err)
+	sort.SliceStable(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+	assert.Equal(t, items, result)
+}
+
+func TestStream_SortExternalStop(t *testing.T) {
+	less := func(a, b interface{}) bool {
+		return a.(int) > b.(int)
+	}
+	dir := t.TempDir()
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = i
+	}
+	equal(t, Of(items...).SortExternal(less, NewGobCodec(), WithMemoryLimit(64), WithTempDir(dir)).Limit(2),
+		[]interface{}{999, 998})
+	assert.Eventually(t, func() bool {
+		return tempFiles(t, dir) == 0
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, exited := infinite()
+	source := stream.WithContext(ctx).SortExternal(less, NewGobCodec(), WithMemoryLimit(64), WithTempDir(dir)).Chan()
+	assert.Eventually(t, func() bool {
+		return tempFiles(t, dir) != 0
+	}, time.Second, time.Millisecond)
+	cancel()
+	drain(source)
+	<-exited
+	assert.Eventually(t, func() bool {
+		return tempFiles(t, dir) == 0
+	}, time.Second, time.Millisecond)
+}
+
+// failedCodec fails to encode the elements.
+type failedCodec struct {
+	Codec
+}
+
+var errCodec = errors.New("codec")
+
+func (failedCodec) Marshal(item interface{}) ([]byte, error) {
+	return nil, errCodec
+}
+
+func TestStream_SortExternalErr(t *testing.T) {
+	stream := Of(1, 2).SortExternal(func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}, failedCodec{NewGobCodec()})
+	equal(t, stream, []interface{}{})
+	assert.Equal(t, errCodec, stream.Err())
+}
+
+func TestGobCodec(t *testing.T) {
+	codec := NewGobCodec()
+	for _, item := range []interface{}{1, "a", 1.5, []int{1, 2}} {
+		data, err := codec.Marshal(item)
+		assert.NoError(t, err)
+		decoded, err := codec.Unmarshal(data)
+		assert.NoError(t, err)
+		assert.Equal(t, item, decoded)
+	}
+	_, err := codec.Unmarshal([]byte("invalid"))
+	assert.Error(t, err)
+}
+
+func TestStream_SortExternalManyRuns(t *testing.T) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("the open files can not be counted")
+	}
+	before := len(fds)
+
+	gob.Register(sortItem{})
+	items := make([]interface{}, 500)
+	for i := range items {
+		items[i] = sortItem{Key: rand.Intn(10), Value: i}
+	}
+	less := func(a, b interface{}) bool {
+		return a.(sortItem).Key < b.(sortItem).Key
+	}
+	dir := t.TempDir()
+	// each element is spilled into a run of its own.
+	source := Of(items...).SortExternal(less, NewGobCodec(), WithMemoryLimit(1), WithTempDir(dir)).Chan()
+	result := []interface{}{<-source}
+	assert.LessOrEqual(t, tempFiles(t, dir), maxMergeFanIn)
+	fds, err = os.ReadDir("/proc/self/fd")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, len(fds), before+maxMergeFanIn+1)
+	for item := range source {
+		result = append(result, item)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return less(items[i], items[j])
+	})
+	assert.Equal(t, items, result)
+	assert.Equal(t, 0, tempFiles(t, dir))
+}
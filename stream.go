@@ -19,12 +19,16 @@
 package stream
 
 import (
+	"context"
 	"errors"
 	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 type (
+	// AccumulateFunc defines the method to accumulate an element into an accumulator.
+	AccumulateFunc func(acc, item interface{}) interface{}
 	// FilterFunc defines the method to filter a Stream.
 	FilterFunc func(item interface{}) bool
 	// ForAllFunc defines the method to handle all elements in a Stream.
@@ -33,6 +37,8 @@ type (
 	ForEachFunc func(item interface{})
 	// GenerateFunc defines the method to send elements into a Stream.
 	GenerateFunc func(source chan<- interface{})
+	// GenerateContextFunc defines the method to send elements into a Stream until ctx is done.
+	GenerateContextFunc func(ctx context.Context, source chan<- interface{})
 	// KeyFunc defines the method to generate keys for the elements in a Stream.
 	KeyFunc func(item interface{}) interface{}
 	// LessFunc defines the method to compare the elements in a Stream.
@@ -45,43 +51,72 @@ type (
 	ReduceFunc func(pipe <-chan interface{}) (interface{}, error)
 	// WalkFunc defines the method to walk through all the elements in a Stream.
 	WalkFunc func(item interface{}, pipe chan<- interface{})
+
+	// FilterErrFunc defines the method to filter a Stream, which may fail.
+	FilterErrFunc func(item interface{}) (bool, error)
+	// MapErrFunc defines the method to map each element to another object in a Stream, which may fail.
+	MapErrFunc func(item interface{}) (interface{}, error)
+	// WalkErrFunc defines the method to walk through all the elements in a Stream, which may fail.
+	WalkErrFunc func(item interface{}, pipe chan<- interface{}) error
+
+	// FilterErrContextFunc defines the method like FilterErrFunc, which should return as soon as ctx is done.
+	FilterErrContextFunc func(ctx context.Context, item interface{}) (bool, error)
+	// MapErrContextFunc defines the method like MapErrFunc, which should return as soon as ctx is done.
+	MapErrContextFunc func(ctx context.Context, item interface{}) (interface{}, error)
+	// WalkErrContextFunc defines the method like WalkErrFunc, which should return as soon as ctx is done.
+	WalkErrContextFunc func(ctx context.Context, item interface{}, pipe chan<- interface{}) error
 )
 
 // Stream Represents a stream.
+// A Stream is lazy, its stages are started until a terminal operation such as Finish or Chan is called.
 type Stream struct {
 	source <-chan interface{}
+	// pipeline is shared by all the stages derived from the Stream.
+	pipeline *pipeline
+	// cancel stops the stage which produces source.
+	cancel context.CancelFunc
+
+	// start starts the stage which produces source, it is nil if the stage is always started.
+	start  func() (source <-chan interface{}, cancel context.CancelFunc)
+	once   sync.Once
+	opened atomic.Bool
+
+	// fused are the operators fused into the stage, they are applied to the elements of upstream in turn.
+	fused    []fusedOperator
+	upstream *Stream
+	// downstreams is the number of the stages built on the Stream.
+	downstreams atomic.Int32
 }
 
-// empty a empty Stream.
-var empty *Stream
+// empty a closed source shared by the empty Streams.
+var empty = make(chan interface{})
 
 func init() {
-	source := make(chan interface{})
-	close(source)
-	empty = &Stream{source}
+	close(empty)
 }
 
 // Empty Returns a empty stream.
 func Empty() *Stream {
-	return empty
+	return Range(empty)
 }
 
 // Range Returns a Stream from source channel.
 func Range(source <-chan interface{}) *Stream {
 	return &Stream{
-		source: source,
+		source:   source,
+		pipeline: newPipeline(context.Background()),
+		cancel:   func() {},
 	}
 }
 
 // Of Returns a Stream based any element
 func Of(items ...interface{}) *Stream {
+	// the source never blocks since it is large enough to hold all the items.
 	source := make(chan interface{}, len(items))
-	go func() {
-		for _, item := range items {
-			source <- item
-		}
-		close(source)
-	}()
+	for _, item := range items {
+		source <- item
+	}
+	close(source)
 	return Range(source)
 }
 
@@ -91,70 +126,112 @@ func Concat(a *Stream, others ...*Stream) *Stream {
 }
 
 // From Returns a Stream from generate function.
+// The generate function can not be interrupted, it is left blocked on sending forever once the Stream
+// is stopped by Limit, FindFirst, AnyMach or a done ctx, along with its goroutine.
+//
+// Deprecated: Use FromContext, whose generate function can return once the Stream is stopped.
 func From(generate GenerateFunc) *Stream {
-	source := make(chan interface{})
-
-	go NewGoroutine(func() {
-		defer close(source)
+	p := newPipeline(context.Background())
+	fn := func(ctx context.Context, source chan interface{}) {
 		generate(source)
+	}
+	return &Stream{
+		pipeline: p,
+		start: func() (<-chan interface{}, context.CancelFunc) {
+			// draining source would let generate run forever, since it can not see ctx.
+			return startStage(p, "From", 0, fn, nil, false)
+		},
+	}
+}
+
+// FromContext Returns a Stream bound to ctx from generate function.
+// The generate function should return as soon as ctx is done.
+func FromContext(ctx context.Context, generate GenerateContextFunc) *Stream {
+	return newStage(newPipeline(ctx), "FromContext", 0, func(ctx context.Context, source chan interface{}) {
+		generate(ctx, source)
 	})
-	return Range(source)
+}
+
+// WithContext Returns a Stream bound to ctx.
+// Once ctx is done, all the stages of the Stream are stopped.
+func (s *Stream) WithContext(ctx context.Context) *Stream {
+	return newStage(newPipeline(ctx), "WithContext", 0, func(ctx context.Context, source chan interface{}) {
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				return
+			}
+			source <- item
+		}
+	}, s)
 }
 
 // Distinct Returns a distinct Stream.
 func (s *Stream) Distinct(f KeyFunc) *Stream {
-	source := make(chan interface{})
-
-	go NewGoroutine(func() {
-		defer close(source)
+	return s.applyState("Distinct", func() applyFunc {
 		unique := make(map[interface{}]struct{})
-		for item := range s.source {
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
 			k := f(item)
 			if _, ok := unique[k]; !ok {
-				source <- item
+				emit(item)
 				unique[k] = struct{}{}
 			}
+			return nil
 		}
 	})
-	return Range(source)
 }
 
 // Count Returns a number that the elements total size.
+// Use CountErr to check whether the Stream is failed as well.
 func (s *Stream) Count() (count int) {
-	for range s.source {
+	for range s.open() {
 		count++
 	}
 	return
 }
 
+// CountErr Returns a number that the elements total size, and the error of the Stream.
+func (s *Stream) CountErr() (int, error) {
+	count := s.Count()
+	return count, s.Err()
+}
+
 // Buffer Returns a buffer Stream.
 func (s *Stream) Buffer(n int) *Stream {
 	if n < 0 {
 		n = 0
 	}
-	source := make(chan interface{}, n)
-	go func() {
-		for item := range s.source {
+	return s.stage("Buffer", n, func(ctx context.Context, source chan interface{}) {
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				return
+			}
 			source <- item
 		}
-		close(source)
-	}()
-
-	return Range(source)
+	})
 }
 
-// Finish Done Stream.
-func (s *Stream) Finish(fs ...func(item interface{})) {
-	for item := range s.source {
+// Finish Done Stream, returns the error of the Stream.
+func (s *Stream) Finish(fs ...func(item interface{})) error {
+	for item := range s.open() {
 		for _, f := range fs {
 			f(item)
 		}
 	}
+	return s.Err()
+}
+
+// Err Returns the error of the Stream, it should be called after the Stream is consumed.
+// It returns the first error if the Stream failed fast, or all the collected errors,
+// or the error of the context bound to the Stream.
+func (s *Stream) Err() error {
+	return s.pipeline.err()
 }
 
 // Chan Returns a channel of Stream.
 func (s *Stream) Chan() <-chan interface{} {
-	return s.source
+	return s.open()
 }
 
 // Split Returns a split Stream that contains multiple slices of chunk size n.
@@ -162,22 +239,23 @@ func (s *Stream) Split(n int) *Stream {
 	if n < 1 {
 		panic("n should be greater than 0")
 	}
-	source := make(chan interface{})
-	go func() {
+	return s.stage("Split", 0, func(ctx context.Context, source chan interface{}) {
 		var chunk []interface{}
-		for item := range s.source {
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				break
+			}
 			chunk = append(chunk, item)
 			if len(chunk) == n {
 				source <- chunk
 				chunk = nil
 			}
 		}
-		if chunk != nil {
+		if chunk != nil && ctx.Err() == nil {
 			source <- chunk
 		}
-		close(source)
-	}()
-	return Range(source)
+	})
 }
 
 // SplitSteam Returns a split Stream that contains multiple stream of chunk size n.
@@ -185,40 +263,41 @@ func (s *Stream) SplitSteam(n int) *Stream {
 	if n < 1 {
 		panic("n should be greater than 0")
 	}
-	source := make(chan interface{})
-
-	var chunkSource = make(chan interface{}, n)
-	go func() {
-
-		for item := range s.source {
+	return s.stage("SplitSteam", 0, func(ctx context.Context, source chan interface{}) {
+		var chunkSource = make(chan interface{}, n)
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				break
+			}
 			chunkSource <- item
 			if len(chunkSource) == n {
-
-				source <- Range(chunkSource)
 				close(chunkSource)
-				chunkSource = nil
+				source <- s.derive(chunkSource)
 				chunkSource = make(chan interface{}, n)
 			}
 		}
-		if len(chunkSource) != 0 {
-			source <- Range(chunkSource)
+		if len(chunkSource) != 0 && ctx.Err() == nil {
 			close(chunkSource)
+			source <- s.derive(chunkSource)
 		}
-		close(source)
-	}()
-	return Range(source)
+	})
 }
 
 // Sort Returns a sorted Stream.
 func (s *Stream) Sort(less LessFunc) *Stream {
-	var items []interface{}
-	for item := range s.source {
-		items = append(items, item)
-	}
-	sort.Slice(items, func(i, j int) bool {
-		return less(items[i], items[j])
+	return s.stage("Sort", 0, func(ctx context.Context, source chan interface{}) {
+		items, ok := collect(ctx, s.source)
+		if !ok {
+			return
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return less(items[i], items[j])
+		})
+		for _, item := range items {
+			source <- item
+		}
 	})
-	return Of(items...)
 }
 
 // Tail Returns a Stream that has n element at the end.
@@ -226,20 +305,22 @@ func (s *Stream) Tail(n int64) *Stream {
 	if n < 1 {
 		panic("n should be greater than 0")
 	}
-	source := make(chan interface{})
-
-	go func() {
+	return s.stage("Tail", 0, func(ctx context.Context, source chan interface{}) {
 		ring := NewRing(int(n))
-		for item := range s.source {
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				break
+			}
 			ring.Add(item)
 		}
+		if ctx.Err() != nil {
+			return
+		}
 		for _, item := range ring.Take() {
 			source <- item
 		}
-		close(source)
-	}()
-
-	return Range(source)
+	})
 }
 
 // Skip Returns a Stream that skips size elements.
@@ -250,58 +331,47 @@ func (s *Stream) Skip(size int) *Stream {
 	if size < 0 {
 		panic("size must be greater than -1")
 	}
-	source := make(chan interface{})
-
-	go func() {
+	return s.applyState("Skip", func() applyFunc {
 		i := 0
-		for item := range s.source {
+		return func(ctx context.Context, item interface{}, emit func(interface{})) error {
 			if i >= size {
-				source <- item
+				emit(item)
 			}
 			i++
+			return nil
 		}
-		close(source)
-	}()
-	return Range(source)
+	})
 }
 
 // Limit Returns a Stream that contains size elements.
+// The upstream is stopped once size elements are sent.
 func (s *Stream) Limit(size int) *Stream {
-	if size == 0 {
-		return Empty()
-	}
 	if size < 0 {
 		panic("size must be greater than -1")
 	}
-	source := make(chan interface{})
-
-	go func() {
-		i := 0
-		for item := range s.source {
-			if i != size {
-				source <- item
+	return s.stage("Limit", 0, func(ctx context.Context, source chan interface{}) {
+		for i := 0; i < size; i++ {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				return
 			}
-			i++
+			source <- item
 		}
-		close(source)
-	}()
-	return Range(source)
+	})
 }
 
-// Foreach Traversals all elements.
-func (s *Stream) Foreach(f ForEachFunc) {
-	items := make([]interface{}, 0)
-	for item := range s.source {
+// Foreach Traversals all elements, returns the error of the Stream.
+func (s *Stream) Foreach(f ForEachFunc) error {
+	for item := range s.open() {
 		f(item)
-		items = append(items, item)
 	}
-
+	return s.Err()
 }
 
 // ForeachOrdered Traversals all elements in reverse order.
 func (s *Stream) ForeachOrdered(f ForEachFunc) {
 	items := make([]interface{}, 0)
-	for item := range s.source {
+	for item := range s.open() {
 		items = append(items, item)
 	}
 	n := len(items)
@@ -313,84 +383,293 @@ func (s *Stream) ForeachOrdered(f ForEachFunc) {
 
 // Concat Returns a Stream that concat others streams
 func (s *Stream) Concat(others ...*Stream) *Stream {
-	source := make(chan interface{})
-	wg := sync.WaitGroup{}
+	streams := []*Stream{s}
 	for _, other := range others {
 		if s == other {
 			continue
 		}
-		wg.Add(1)
-		go func(iother *Stream) {
-			for item := range iother.source {
-				source <- item
-			}
-			wg.Done()
-		}(other)
-
+		streams = append(streams, other)
 	}
 
-	wg.Add(1)
-	go func() {
-		for item := range s.source {
-			source <- item
+	return newStage(s.pipeline, "Concat", 0, func(ctx context.Context, source chan interface{}) {
+		wg := sync.WaitGroup{}
+		for _, stream := range streams {
+			wg.Add(1)
+			go func(istream *Stream) {
+				defer wg.Done()
+				for {
+					item, ok := receive(ctx, istream.source)
+					if !ok {
+						return
+					}
+					source <- item
+				}
+			}(stream)
 		}
-		wg.Done()
-	}()
-	go func() {
 		wg.Wait()
-		close(source)
-	}()
-	return Range(source)
+	}, streams...)
 }
 
 // Filter Returns a Stream that
 func (s *Stream) Filter(fn FilterFunc, opts ...Option) *Stream {
-	return s.Walk(func(item interface{}, pipe chan<- interface{}) {
+	return s.apply("Filter", func(ctx context.Context, item interface{}, emit func(interface{})) error {
 		if fn(item) {
-			pipe <- item
+			emit(item)
+		}
+		return nil
+	}, opts...)
+}
+
+// FilterErr Returns a Stream like Filter, the elements failed to be filtered are dropped
+// and the errors are handled according to the ErrorMode.
+func (s *Stream) FilterErr(fn FilterErrFunc, opts ...Option) *Stream {
+	return s.apply("FilterErr", func(ctx context.Context, item interface{}, emit func(interface{})) error {
+		ok, err := fn(item)
+		if err != nil {
+			return err
+		}
+		if ok {
+			emit(item)
+		}
+		return nil
+	}, opts...)
+}
+
+// FilterErrContext Returns a Stream like FilterErr, fn receives the ctx which is done once the Stream is stopped
+// or the attempt is timed out, see WithRetry.
+func (s *Stream) FilterErrContext(fn FilterErrContextFunc, opts ...Option) *Stream {
+	return s.apply("FilterErrContext", func(ctx context.Context, item interface{}, emit func(interface{})) error {
+		ok, err := fn(ctx, item)
+		if err != nil {
+			return err
 		}
+		if ok {
+			emit(item)
+		}
+		return nil
 	}, opts...)
 }
 
 // Walk Returns a Stream that lets the callers handle each item, the caller may write zero,
 // one or more items base on the given item.
+// The items are written in the order of the given items if the option WithOrdered is given.
 func (s *Stream) Walk(f WalkFunc, opts ...Option) *Stream {
-	option := loadOptions(opts...)
-	pipe := make(chan interface{}, option.workSize)
+	fn, release := applyWalk(func(ctx context.Context, item interface{}, pipe chan<- interface{}) error {
+		f(item, pipe)
+		return nil
+	})
+	return s.walk("Walk", loadOptions(opts...), fn, release)
+}
+
+// WalkErr Returns a Stream like Walk, the errors returned by f are handled according to the ErrorMode.
+// The results are sent in the order of the elements if the option WithOrdered is given.
+// The panics of elements are logged by default, use the options such as WithPanicHandler to change it.
+// In FailFast mode, the whole Stream is stopped on the first error,
+// In CollectAll mode, the Stream keeps running and all the errors are collected.
+// The errors can be got from the terminal operations such as Finish or Err.
+func (s *Stream) WalkErr(f WalkErrFunc, opts ...Option) *Stream {
+	fn, release := applyWalk(func(ctx context.Context, item interface{}, pipe chan<- interface{}) error {
+		return f(item, pipe)
+	})
+	return s.walk("WalkErr", loadOptions(opts...), fn, release)
+}
+
+// WalkErrContext Returns a Stream like WalkErr, f receives the ctx which is done once the Stream is stopped
+// or the attempt is timed out, see WithRetry.
+func (s *Stream) WalkErrContext(f WalkErrContextFunc, opts ...Option) *Stream {
+	fn, release := applyWalk(f)
+	return s.walk("WalkErrContext", loadOptions(opts...), fn, release)
+}
+
+// walk Returns a Stream that lets operator handle each item by f,
+// release is called once the stage is done if it is not nil.
+func (s *Stream) walk(operator string, option *Options, f applyFunc, release func()) *Stream {
+	walk := func(ctx context.Context, item interface{}, emit func(interface{})) {
+		s.handle(operator, option, item, func() error {
+			return option.attempt(ctx, f, item, emit)
+		})
+	}
+	run := s.walkUnordered
+	switch {
+	case option.ordered:
+		run = s.walkOrdered
+	case option.shardKey != nil:
+		run = s.walkSharded
+	case option.workerPool != nil:
+		run = s.walkPooled
+	}
+
+	return s.stage(operator, option.workSize, func(ctx context.Context, pipe chan interface{}) {
+		if release != nil {
+			defer release()
+		}
+		run(ctx, walk, pipe, option)
+	})
+}
+
+// walkUnordered walks through the elements of s with the workers given by option, each element is walked
+// by a new goroutine, and the results are sent into pipe as soon as they are emitted.
+func (s *Stream) walkUnordered(ctx context.Context, walk func(ctx context.Context, item interface{}, emit func(interface{})),
+	pipe chan<- interface{}, option *Options) {
+	var wg sync.WaitGroup
+	pool := make(chan struct{}, option.workSize)
+	emit := func(v interface{}) {
+		pipe <- v
+	}
+
+	for {
+		pool <- struct{}{}
+		item, ok := receive(ctx, s.source)
+		if !ok || option.wait(ctx) != nil {
+			<-pool
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				wg.Done()
+				<-pool
+			}()
+			walk(ctx, item, emit)
+		}()
+	}
+	wg.Wait()
+}
+
+// walkPooled walks through the elements of s with the WorkerPool given by option.
+// The results of each element are buffered and sent into pipe after its worker is released,
+// so that the stages sharing the WorkerPool never hold the workers while they wait for each other.
+func (s *Stream) walkPooled(ctx context.Context, walk func(ctx context.Context, item interface{}, emit func(interface{})),
+	pipe chan<- interface{}, option *Options) {
+	// an element holds its slot of pool until its results are sent, so the buffered results are
+	// bounded by the size of work, and the workers are never blocked by sending them into batches.
+	pool := make(chan struct{}, option.workSize)
+	batches := make(chan []interface{}, option.workSize)
+	sent := make(chan struct{})
 	go func() {
-		var wg sync.WaitGroup
-		pool := make(chan struct{}, option.workSize)
+		defer close(sent)
+		for batch := range batches {
+			for _, item := range batch {
+				pipe <- item
+			}
+			<-pool
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for {
+		pool <- struct{}{}
+		item, ok := receive(ctx, s.source)
+		if !ok || option.wait(ctx) != nil {
+			break
+		}
+
+		wg.Add(1)
+		err := option.run(ctx, func() {
+			defer wg.Done()
+			var results []interface{}
+			walk(ctx, item, func(v interface{}) {
+				results = append(results, v)
+			})
+			batches <- results
+		})
+		if err != nil {
+			wg.Done()
+			s.failRun(err)
+			break
+		}
+	}
+	wg.Wait()
+	close(batches)
+	<-sent
+}
 
+// failRun fails the Stream if an element can not be run since the WorkerPool is closed,
+// the other errors are caused by stopping the Stream.
+func (s *Stream) failRun(err error) {
+	if errors.Is(err, ErrWorkerPoolClosed) {
+		s.pipeline.fail(err, true)
+	}
+}
+
+// walkOrdered walks through the elements of s with the workers given by option, and sends the results
+// into pipe in the order of the elements.
+func (s *Stream) walkOrdered(ctx context.Context, walk func(ctx context.Context, item interface{}, emit func(interface{})),
+	pipe chan<- interface{}, option *Options) {
+	// the results of each element are buffered and sent into its own channel, the channels are queued
+	// in the order of the elements, so the elements in progress are bounded by the size of the queue,
+	// and the workers are released without waiting for the results of the former elements to be sent.
+	queue := make(chan chan []interface{}, option.workSize-1)
+	go func() {
+		defer close(queue)
 		for {
-			pool <- struct{}{}
-			item, ok := <-s.source
-			if !ok {
-				<-pool
-				break
+			item, ok := receive(ctx, s.source)
+			if !ok || option.wait(ctx) != nil {
+				return
 			}
 
-			wg.Add(1)
-			// better to safely run caller defined method
-			go NewGoroutine(func() {
-				defer func() {
-					wg.Done()
-					<-pool
-				}()
-				f(item, pipe)
+			results := make(chan []interface{}, 1)
+			select {
+			case queue <- results:
+			case <-ctx.Done():
+				return
+			}
+			err := option.run(ctx, func() {
+				defer close(results)
+				var batch []interface{}
+				walk(ctx, item, func(v interface{}) {
+					batch = append(batch, v)
+				})
+				results <- batch
 			})
+			if err != nil {
+				close(results)
+				s.failRun(err)
+				return
+			}
 		}
-		wg.Wait()
-		close(pipe)
 	}()
 
-	return Range(pipe)
+	for results := range queue {
+		for _, item := range <-results {
+			pipe <- item
+		}
+	}
 }
 
 // Map Returns a Stream consisting of the results of applying the given
 // function to the elements of this stream.
 func (s *Stream) Map(fn MapFunc, opts ...Option) *Stream {
-	return s.Walk(func(item interface{}, pipe chan<- interface{}) {
-		pipe <- fn(item)
+	return s.apply("Map", func(ctx context.Context, item interface{}, emit func(interface{})) error {
+		emit(fn(item))
+		return nil
+	}, opts...)
+}
+
+// MapErr Returns a Stream like Map, the elements failed to be mapped are dropped
+// and the errors are handled according to the ErrorMode.
+func (s *Stream) MapErr(fn MapErrFunc, opts ...Option) *Stream {
+	return s.apply("MapErr", func(ctx context.Context, item interface{}, emit func(interface{})) error {
+		v, err := fn(item)
+		if err != nil {
+			return err
+		}
+		emit(v)
+		return nil
+	}, opts...)
+}
+
+// MapErrContext Returns a Stream like MapErr, fn receives the ctx which is done once the Stream is stopped
+// or the attempt is timed out, see WithRetry.
+func (s *Stream) MapErrContext(fn MapErrContextFunc, opts ...Option) *Stream {
+	return s.apply("MapErrContext", func(ctx context.Context, item interface{}, emit func(interface{})) error {
+		v, err := fn(ctx, item)
+		if err != nil {
+			return err
+		}
+		emit(v)
+		return nil
 	}, opts...)
 }
 
@@ -398,76 +677,98 @@ func (s *Stream) Map(fn MapFunc, opts ...Option) *Stream {
 // a mapped stream produced by applying the provided mapping function to each element. Each mapped stream is closed
 // after its contents have been placed into this stream. (If a mapped stream is null an empty stream is used, instead.
 func (s *Stream) FlatMap(fn MapFunc, opts ...Option) *Stream {
-	return s.Walk(func(item interface{}, pipe chan<- interface{}) {
+	return s.apply("FlatMap", func(ctx context.Context, item interface{}, emit func(interface{})) error {
 		switch v := item.(type) {
 		case []interface{}:
 			for _, x := range v {
-				pipe <- fn(x)
+				emit(fn(x))
 			}
 		case interface{}:
-			pipe <- fn(v)
+			emit(fn(v))
 		}
+		return nil
 	}, opts...)
 }
 
 // Group Returns a Stream that groups the elements into different groups based on their keys.
-func (s *Stream) Group(f KeyFunc) *Stream {
-	groups := make(map[interface{}][]interface{})
-	for item := range s.source {
-		key := f(item)
-		groups[key] = append(groups[key], item)
-	}
-
-	source := make(chan interface{})
-	go func() {
+// The groups are emitted in random order, or in the order their keys are first seen if the option
+// WithInsertionOrder is given.
+func (s *Stream) Group(f KeyFunc, opts ...Option) *Stream {
+	option := loadOptions(opts...)
+	return s.stage("Group", 0, func(ctx context.Context, source chan interface{}) {
+		groups := make(map[interface{}][]interface{})
+		var keys []interface{}
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				break
+			}
+			key := f(item)
+			if _, ok := groups[key]; !ok && option.insertionOrder {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], item)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if option.insertionOrder {
+			for _, key := range keys {
+				source <- groups[key]
+			}
+			return
+		}
 		for _, group := range groups {
 			source <- group
 		}
-		close(source)
-	}()
-
-	return Range(source)
+	})
 }
 
 // Merge Returns a Stream that merges all the items into a slice and generates a new stream.
 func (s *Stream) Merge() *Stream {
-	var items []interface{}
-	for item := range s.source {
-		items = append(items, item)
-	}
-
-	source := make(chan interface{}, 1)
-	source <- items
-	close(source)
-
-	return Range(source)
+	return s.stage("Merge", 1, func(ctx context.Context, source chan interface{}) {
+		items, ok := collect(ctx, s.source)
+		if !ok {
+			return
+		}
+		source <- items
+	})
 }
 
 // Reverse Returns a Stream that reverses the elements.
 func (s *Stream) Reverse() *Stream {
-	var items []interface{}
-	for item := range s.source {
-		items = append(items, item)
-	}
-	for i := len(items)/2 - 1; i >= 0; i-- {
-		opp := len(items) - 1 - i
-		items[i], items[opp] = items[opp], items[i]
-	}
-	return Of(items...)
+	return s.stage("Reverse", 0, func(ctx context.Context, source chan interface{}) {
+		items, ok := collect(ctx, s.source)
+		if !ok {
+			return
+		}
+		for i := len(items)/2 - 1; i >= 0; i-- {
+			opp := len(items) - 1 - i
+			items[i], items[opp] = items[opp], items[i]
+		}
+		for _, item := range items {
+			source <- item
+		}
+	})
 }
 
-// ParallelFinish applies the given ParallelFunc to each item concurrently with given number of workers
-func (s *Stream) ParallelFinish(fn ParallelFunc, opts ...Option) {
-	s.Walk(func(item interface{}, pipe chan<- interface{}) {
+// ParallelFinish applies the given ParallelFunc to each item concurrently with given number of workers,
+// returns the error of the Stream.
+func (s *Stream) ParallelFinish(fn ParallelFunc, opts ...Option) error {
+	return s.apply("ParallelFinish", func(ctx context.Context, item interface{}, emit func(interface{})) error {
 		fn(item)
+		return nil
 	}, opts...).Finish()
 }
 
 // AnyMach Returns whether any elements of this stream match the provided predicate.
 // May not evaluate the predicate on all elements if not necessary for determining the result.
 // If the stream is empty then false is returned and the predicate is not evaluated.
+// The upstream is stopped once the result is determined.
 func (s *Stream) AnyMach(f func(item interface{}) bool) (isFind bool) {
-	for item := range s.source {
+	source := s.open()
+	defer s.cancel()
+	for item := range source {
 		if f(item) {
 			isFind = true
 			return
@@ -479,9 +780,12 @@ func (s *Stream) AnyMach(f func(item interface{}) bool) (isFind bool) {
 // AllMach Returns whether all elements of this stream match the provided predicate.
 // May not evaluate the predicate on all elements if not necessary for determining the result.
 // If the stream is empty then true is returned and the predicate is not evaluated.
+// The upstream is stopped once the result is determined.
 func (s *Stream) AllMach(f func(item interface{}) bool) (isFind bool) {
+	source := s.open()
+	defer s.cancel()
 	isFind = true
-	for item := range s.source {
+	for item := range source {
 		if !f(item) {
 			isFind = false
 			return
@@ -490,27 +794,142 @@ func (s *Stream) AllMach(f func(item interface{}) bool) (isFind bool) {
 	return
 }
 
-// FindFirst Returns an interface{} the first element of this stream, or a nil and a error if the stream is empty.
-// If the stream has no encounter order, then any element may be returned
+// FindFirst Returns an interface{} the first element of this stream, or a nil and ErrNoElement if the stream is empty.
+// If the stream has no encounter order, then any element may be returned.
+// The upstream is stopped once the first element is found.
 func (s *Stream) FindFirst() (result interface{}, err error) {
-	for item := range s.source {
+	source := s.open()
+	defer s.cancel()
+	for item := range source {
 		result = item
 		return
 	}
-	err = errors.New("no element")
+	err = ErrNoElement
 	return
 }
 
 // Peek Returns a Stream consisting of the elements of this stream,
 // additionally performing the provided action on each element as elements are consumed from the resulting stream.
 func (s *Stream) Peek(f ForEachFunc) *Stream {
-	source := make(chan interface{})
-	go func() {
-		for item := range s.source {
-			source <- item
-			f(item)
+	return s.apply("Peek", func(ctx context.Context, item interface{}, emit func(interface{})) error {
+		emit(item)
+		f(item)
+		return nil
+	})
+}
+
+// stage Returns a Stream whose elements are sent by fn from a new goroutine, s is the upstream of the Stream.
+func (s *Stream) stage(operator string, n int, fn func(ctx context.Context, source chan interface{})) *Stream {
+	return newStage(s.pipeline, operator, n, fn, s)
+}
+
+// derive Returns a Stream from source channel that shares the pipeline of s.
+func (s *Stream) derive(source <-chan interface{}) *Stream {
+	return &Stream{
+		source:   source,
+		pipeline: s.pipeline,
+		cancel:   func() {},
+	}
+}
+
+// open starts the stage of s and its upstreams if they are not started, and returns the source of s.
+func (s *Stream) open() <-chan interface{} {
+	s.once.Do(func() {
+		s.opened.Store(true)
+		if s.start != nil {
+			s.source, s.cancel = s.start()
 		}
-		close(source)
+	})
+	return s.source
+}
+
+// newStage Returns a Stream of pipeline p whose elements are sent by fn of operator from a new goroutine.
+// The stage is started lazily with its upstreams, the ctx passed to fn is done when p is done or
+// the Stream is stopped, and once fn returns, the Stream is closed, all the upstreams are stopped
+// and their errors are merged into p.
+func newStage(p *pipeline, operator string, n int, fn func(ctx context.Context, source chan interface{}), upstreams ...*Stream) *Stream {
+	for _, upstream := range upstreams {
+		upstream.downstreams.Add(1)
+	}
+	return &Stream{
+		pipeline: p,
+		start: func() (<-chan interface{}, context.CancelFunc) {
+			for _, upstream := range upstreams {
+				upstream.open()
+			}
+			return startStage(p, operator, n, fn, upstreams, true)
+		},
+	}
+}
+
+// startStage starts the stage created by newStage, source is drained once the Stream is stopped if drainOnStop is true.
+func startStage(p *pipeline, operator string, n int, fn func(ctx context.Context, source chan interface{}),
+	upstreams []*Stream, drainOnStop bool) (<-chan interface{}, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(p.ctx)
+	source := make(chan interface{}, n)
+	done := make(chan struct{})
+
+	// unblock the goroutines which are still sending elements after the Stream is stopped.
+	if drainOnStop {
+		go func() {
+			select {
+			case <-ctx.Done():
+				select {
+				case <-done:
+					// the elements left in source are still wanted.
+				default:
+					drain(source)
+				}
+			case <-done:
+			}
+		}()
+	}
+
+	go func() {
+		defer func() {
+			// the panic fails p before the Stream is closed, so that it is reported by the terminal operations.
+			if r := recover(); r != nil {
+				if again, ok := r.(rePanic); ok {
+					panic(again.recovered)
+				}
+				recoverOperator(p, operator, r)
+			}
+			close(done)
+			cancel()
+			for _, upstream := range upstreams {
+				upstream.cancel()
+				p.merge(upstream.pipeline)
+			}
+			close(source)
+		}()
+		fn(ctx, source)
 	}()
-	return Range(source)
+
+	return source, cancel
+}
+
+// receive Returns an element from source, ok is false if source is closed or ctx is done.
+func receive(ctx context.Context, source <-chan interface{}) (item interface{}, ok bool) {
+	select {
+	case item, ok = <-source:
+	case <-ctx.Done():
+	}
+	return
+}
+
+// collect Returns all the elements from source, ok is false if ctx is done.
+func collect(ctx context.Context, source <-chan interface{}) (items []interface{}, ok bool) {
+	for {
+		item, ok := receive(ctx, source)
+		if !ok {
+			return items, ctx.Err() == nil
+		}
+		items = append(items, item)
+	}
+}
+
+// drain Drains source until it is closed.
+func drain(source <-chan interface{}) {
+	for range source {
+	}
 }
@@ -19,15 +19,21 @@
 package stream
 
 import (
+	"context"
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/multierr"
+	"math/rand"
 	"reflect"
 	"sort"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func equal(t *testing.T, stream *Stream, data []interface{}) {
 	items := make([]interface{}, 0)
-	for item := range stream.source {
+	for item := range stream.Chan() {
 		items = append(items, item)
 	}
 	if !reflect.DeepEqual(items, data) {
@@ -44,8 +50,8 @@ func assertEqual(t *testing.T, except interface{}, data interface{}) {
 
 func TestEmpty(t *testing.T) {
 	empty := Empty()
-	assertEqual(t, len(empty.source), 0)
-	assertEqual(t, cap(empty.source), 0)
+	assertEqual(t, len(empty.Chan()), 0)
+	assertEqual(t, cap(empty.Chan()), 0)
 	empty.Foreach(func(item interface{}) {
 
 	})
@@ -53,11 +59,11 @@ func TestEmpty(t *testing.T) {
 
 func TestRange(t *testing.T) {
 	stream1 := Range(make(chan interface{}))
-	assertEqual(t, len(stream1.source), 0)
+	assertEqual(t, len(stream1.Chan()), 0)
 
 	stream2 := Range(make(chan interface{}, 2))
-	assertEqual(t, len(stream2.source), 0)
-	assertEqual(t, cap(stream2.source), 2)
+	assertEqual(t, len(stream2.Chan()), 0)
+	assertEqual(t, cap(stream2.Chan()), 2)
 }
 
 func TestOf(t *testing.T) {
@@ -66,7 +72,7 @@ func TestOf(t *testing.T) {
 		return a.(int) < b.(int)
 	})
 	var items []interface{}
-	for item := range of.source {
+	for item := range of.Chan() {
 		items = append(items, item)
 	}
 	assertEqual(t, items, ints)
@@ -79,7 +85,7 @@ func TestConcat(t *testing.T) {
 	s2 := Of(a2...)
 	stream := Concat(s1, s2)
 	var items []interface{}
-	for item := range stream.source {
+	for item := range stream.Chan() {
 		items = append(items, item)
 	}
 	sort.Slice(items, func(i, j int) bool {
@@ -104,12 +110,42 @@ func TestFrom(t *testing.T) {
 		}
 	})
 	items := make([]interface{}, 0)
-	for item := range stream.source {
+	for item := range stream.Chan() {
 		items = append(items, item)
 	}
 	assertEqual(t, items, ints)
 }
 
+func TestFrom_Stopped(t *testing.T) {
+	var generated int64
+	stream := From(func(source chan<- interface{}) {
+		for i := 0; ; i++ {
+			source <- i
+			atomic.AddInt64(&generated, 1)
+		}
+	})
+	assert.Equal(t, 3, stream.Limit(3).Count())
+
+	// the generate function is left blocked instead of being drained.
+	time.Sleep(50 * time.Millisecond)
+	assert.LessOrEqual(t, atomic.LoadInt64(&generated), int64(4))
+
+	// the generate function of FromContext exits once the Stream is stopped.
+	exited := make(chan struct{})
+	stream = FromContext(context.Background(), func(ctx context.Context, source chan<- interface{}) {
+		defer close(exited)
+		for i := 0; ; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	assert.Equal(t, 3, stream.Limit(3).Count())
+	<-exited
+}
+
 func TestStream_Distinct(t *testing.T) {
 	stream := Of(1, 2, 3, 4, 4, 22, 2, 1, 4).Distinct(func(item interface{}) interface{} {
 		return item
@@ -122,13 +158,29 @@ func TestStream_Count(t *testing.T) {
 	assertEqual(t, Of(data...).Count(), len(data))
 }
 
+func TestStream_CountErr(t *testing.T) {
+	count, err := Of(1, 2, 3).CountErr()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	errOdd := errors.New("odd")
+	count, err = Of(1, 2, 3).MapErr(func(item interface{}) (interface{}, error) {
+		if item.(int)%2 == 1 {
+			return nil, errOdd
+		}
+		return item, nil
+	}, WithErrorMode(CollectAll)).CountErr()
+	assert.Equal(t, 1, count)
+	assert.Equal(t, multierr.Combine(errOdd, errOdd), err)
+}
+
 func TestStream_Buffer(t *testing.T) {
 	stream := Of(1, 2, 4)
-	assertEqual(t, cap(stream.source), 3)
+	assertEqual(t, cap(stream.Chan()), 3)
 	stream = stream.Buffer(10)
-	assertEqual(t, cap(stream.source), 10)
+	assertEqual(t, cap(stream.Chan()), 10)
 	stream = stream.Buffer(-1)
-	assertEqual(t, cap(stream.source), 0)
+	assertEqual(t, cap(stream.Chan()), 0)
 
 }
 
@@ -150,8 +202,8 @@ func TestStream_Finish(t *testing.T) {
 func TestStream_Split(t *testing.T) {
 
 	stream := Of(1, 2, 444, 441, 1).Split(3)
-	assertEqual(t, (<-stream.source).([]interface{}), []interface{}{1, 2, 444})
-	assertEqual(t, (<-stream.source).([]interface{}), []interface{}{441, 1})
+	assertEqual(t, (<-stream.Chan()).([]interface{}), []interface{}{1, 2, 444})
+	assertEqual(t, (<-stream.Chan()).([]interface{}), []interface{}{441, 1})
 	assert.Panics(t, func() {
 		Of(1, 2, 444, 441, 1).Split(-1)
 	})
@@ -163,8 +215,8 @@ func TestStream_Split(t *testing.T) {
 func TestStream_SplitSteam2(t *testing.T) {
 	streams := Of(1, 2, 444, 441, 1).SplitSteam(3)
 
-	equal(t, (<-streams.source).(*Stream), []interface{}{1, 2, 444})
-	equal(t, (<-streams.source).(*Stream), []interface{}{441, 1})
+	equal(t, (<-streams.Chan()).(*Stream), []interface{}{1, 2, 444})
+	equal(t, (<-streams.Chan()).(*Stream), []interface{}{441, 1})
 }
 
 func TestStream_Sort(t *testing.T) {
@@ -178,6 +230,22 @@ func TestStream_Sort(t *testing.T) {
 	equal(t, stream, ints)
 }
 
+func TestStream_OperatorPanic(t *testing.T) {
+	err := Of(2, 1).Sort(func(a, b interface{}) bool {
+		panic("less")
+	}).Finish()
+	assert.Equal(t, &PanicError{Recovered: "less"}, err)
+
+	stream := Of(1, 2, 3).Group(func(item interface{}) interface{} {
+		if item.(int) == 2 {
+			panic("key")
+		}
+		return item
+	})
+	equal(t, stream, []interface{}{})
+	assert.Equal(t, &PanicError{Recovered: "key"}, stream.Err())
+}
+
 func TestStream_Tail(t *testing.T) {
 	equal(t, Of(1, 232, 3, 2, 3).Tail(1), []interface{}{3})
 	equal(t, Of(1, 232, 3, 2, 3).Tail(2), []interface{}{2, 3})
@@ -231,7 +299,7 @@ func TestStream_ForeachOrdered(t *testing.T) {
 func TestStream_Concat(t *testing.T) {
 	stream := Of(1).Concat(Of(2), Of(3))
 	var items []interface{}
-	for item := range stream.source {
+	for item := range stream.Chan() {
 		items = append(items, item)
 	}
 	sort.Slice(items, func(i, j int) bool {
@@ -354,8 +422,8 @@ func TestStream_Chan(t *testing.T) {
 
 func TestStream_SplitSteam(t *testing.T) {
 	streams := Of(1, 2, 444, 441, 1).SplitSteam(3)
-	equal(t, (<-streams.source).(*Stream), []interface{}{1, 2, 444})
-	equal(t, (<-streams.source).(*Stream), []interface{}{441, 1})
+	equal(t, (<-streams.Chan()).(*Stream), []interface{}{1, 2, 444})
+	equal(t, (<-streams.Chan()).(*Stream), []interface{}{441, 1})
 	assert.Panics(t, func() {
 		Of(1, 2, 444, 441, 1).SplitSteam(-1)
 	})
@@ -375,3 +443,190 @@ func TestStream_FindFirst(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, nil, result)
 }
+
+func TestFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	stream := FromContext(ctx, func(ctx context.Context, source chan<- interface{}) {
+		defer close(exited)
+		for i := 0; ; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	assertEqual(t, 0, <-stream.Chan())
+	cancel()
+	<-exited
+	for range stream.Chan() {
+	}
+}
+
+func TestStream_WithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	stream := FromContext(context.Background(), func(ctx context.Context, source chan<- interface{}) {
+		defer close(exited)
+		for i := 0; i < 100; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}).WithContext(ctx).Map(func(item interface{}) interface{} {
+		return item
+	}, WithWorkSize(4)).Filter(func(item interface{}) bool {
+		return true
+	})
+	<-stream.Chan()
+	cancel()
+	// the generate function is unblocked and all the stages are closed.
+	<-exited
+	for range stream.Chan() {
+	}
+
+	ch := make(chan interface{})
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assertEqual(t, 0, Range(ch).WithContext(ctx).Count())
+}
+
+func TestStream_MapErr(t *testing.T) {
+	errOdd := errors.New("odd")
+	mapFunc := func(item interface{}) (interface{}, error) {
+		if item.(int)%2 == 1 {
+			return nil, errOdd
+		}
+		return item, nil
+	}
+
+	var items []interface{}
+	err := Of(1, 2, 3, 4).MapErr(mapFunc).Foreach(func(item interface{}) {
+		items = append(items, item)
+	})
+	assert.Equal(t, errOdd, err)
+	assert.NotContains(t, items, 3)
+
+	stream := Of(1, 2, 3, 4).MapErr(mapFunc, WithErrorMode(CollectAll))
+	equal(t, stream, []interface{}{2, 4})
+	assert.Len(t, multierr.Errors(stream.Err()), 2)
+}
+
+func TestStream_FilterErr(t *testing.T) {
+	errFour := errors.New("four")
+	stream := Of(1, 2, 3, 4, 5).FilterErr(func(item interface{}) (bool, error) {
+		if item.(int) == 4 {
+			return false, errFour
+		}
+		return item.(int) > 2, nil
+	}, WithErrorMode(CollectAll))
+	equal(t, stream, []interface{}{3, 5})
+	assert.Equal(t, errFour, stream.Err())
+}
+
+func TestStream_WalkErr(t *testing.T) {
+	errWalk := errors.New("walk")
+	err := From(func(source chan<- interface{}) {
+		for i := 0; i < 100; i++ {
+			source <- i
+		}
+	}).WalkErr(func(item interface{}, pipe chan<- interface{}) error {
+		if item.(int) == 10 {
+			return errWalk
+		}
+		pipe <- item
+		return nil
+	}, WithWorkSize(4)).Map(func(item interface{}) interface{} {
+		return item
+	}).Finish()
+	assert.Equal(t, errWalk, err)
+
+	err = Of(1, 2).WalkErr(func(item interface{}, pipe chan<- interface{}) error {
+		return errWalk
+	}).WithContext(context.Background()).Finish()
+	assert.Equal(t, errWalk, err)
+
+	assert.NoError(t, Of(1, 2).ParallelFinish(func(item interface{}) {}))
+}
+
+func TestStream_WalkOrdered(t *testing.T) {
+	var want []interface{}
+	for i := 0; i < 100; i++ {
+		want = append(want, i, -i)
+	}
+	var running, maxRunning int32
+	stream := From(func(source chan<- interface{}) {
+		for i := 0; i < 100; i++ {
+			source <- i
+		}
+	}).Walk(func(item interface{}, pipe chan<- interface{}) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+		pipe <- item
+		pipe <- -item.(int)
+	}, WithWorkSize(8), WithOrdered())
+	equal(t, stream, want)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxRunning), int32(8))
+
+	equal(t, Of(1, 2, 3).Map(func(item interface{}) interface{} {
+		return item.(int) * 2
+	}, WithOrdered()), []interface{}{2, 4, 6})
+}
+
+// infinite Returns a infinite Stream of 0, 1, 2..., exited is closed when the generate function returns.
+func infinite() (stream *Stream, exited <-chan struct{}) {
+	done := make(chan struct{})
+	stream = FromContext(context.Background(), func(ctx context.Context, source chan<- interface{}) {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return stream, done
+}
+
+func TestStream_ShortCircuit(t *testing.T) {
+	stream, exited := infinite()
+	equal(t, stream.Limit(3), []interface{}{0, 1, 2})
+	<-exited
+
+	stream, exited = infinite()
+	equal(t, stream.Map(func(item interface{}) interface{} {
+		return item
+	}, WithWorkSize(4)).Concat(Of(-1)).Limit(0), []interface{}{})
+	<-exited
+
+	stream, exited = infinite()
+	result, err := stream.Skip(2).FindFirst()
+	assert.NoError(t, err)
+	assertEqual(t, 2, result)
+	<-exited
+
+	stream, exited = infinite()
+	assertEqual(t, true, stream.Filter(func(item interface{}) bool {
+		return item.(int)%2 == 0
+	}, WithWorkSize(2)).AnyMach(func(item interface{}) bool {
+		return item.(int) > 10
+	}))
+	<-exited
+
+	stream, exited = infinite()
+	assertEqual(t, false, stream.Peek(func(item interface{}) {}).AllMach(func(item interface{}) bool {
+		return item.(int) < 10
+	}))
+	<-exited
+}
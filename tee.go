@@ -0,0 +1,155 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"sync"
+)
+
+// Backpressure defines how Tee and Broadcast send the elements to the branches which lag behind.
+type Backpressure int
+
+const (
+	// BackpressureBlock blocks until the slowest branch receives the element.
+	BackpressureBlock Backpressure = iota
+	// BackpressureBuffer buffers the elements of each branch, and blocks once the buffer is full.
+	BackpressureBuffer
+	// BackpressureDrop buffers the elements of each branch, and drops the elements once the buffer is full.
+	BackpressureDrop
+)
+
+// WithBackpressure return a Option that set how Tee and Broadcast send the elements to the branches,
+// size is the buffer size of each branch, which is ignored by BackpressureBlock
+func WithBackpressure(backpressure Backpressure, size int) Option {
+	return func(options *Options) {
+		options.backpressure = backpressure
+		options.bufferSize = size
+	}
+}
+
+// Tee Returns n Streams, each of them receives all the elements of s.
+// The elements are sent to the branches in turn according to the option WithBackpressure, so all the branches
+// should be consumed concurrently or stopped. s is stopped once all the branches are stopped,
+// and all the branches end once s ends or the Stream is stopped.
+func (s *Stream) Tee(n int, opts ...Option) []*Stream {
+	if n < 1 {
+		panic("n should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	if option.bufferSize < 0 {
+		panic("size should be greater than -1")
+	}
+	size := option.bufferSize
+	if option.backpressure == BackpressureBlock {
+		size = 0
+	}
+
+	sources := make([]chan interface{}, n)
+	stopped := make([]chan struct{}, n)
+	stops := make([]context.CancelFunc, n)
+	for i := range sources {
+		sources[i] = make(chan interface{}, size)
+		stopped[i] = make(chan struct{})
+		var once sync.Once
+		done := stopped[i]
+		stops[i] = func() {
+			once.Do(func() {
+				close(done)
+			})
+		}
+	}
+
+	// hub sends the elements of s to the branches, it emits nothing itself.
+	hub := s.stage("Tee", 0, func(ctx context.Context, _ chan interface{}) {
+		defer func() {
+			for _, source := range sources {
+				close(source)
+			}
+		}()
+
+		active := make([]bool, n)
+		for i := range active {
+			active[i] = true
+		}
+		for activeCount := n; activeCount != 0; {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				return
+			}
+			for i, source := range sources {
+				if !active[i] {
+					continue
+				}
+				if option.backpressure == BackpressureDrop {
+					select {
+					case source <- item:
+					case <-stopped[i]:
+						active[i] = false
+						activeCount--
+					default:
+					}
+					continue
+				}
+				select {
+				case source <- item:
+				case <-stopped[i]:
+					active[i] = false
+					activeCount--
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+
+	branches := make([]*Stream, n)
+	for i := range branches {
+		source, stop := sources[i], stops[i]
+		branches[i] = &Stream{
+			pipeline: s.pipeline,
+			start: func() (<-chan interface{}, context.CancelFunc) {
+				hub.open()
+				return source, stop
+			},
+		}
+	}
+	return branches
+}
+
+// Broadcast Runs each consumer with a Stream which receives all the elements of s concurrently, see Tee.
+// The Stream of a consumer is stopped once the consumer returns.
+// It returns the error of s once all the consumers return.
+func (s *Stream) Broadcast(consumers []func(*Stream), opts ...Option) error {
+	branches := s.Tee(len(consumers), opts...)
+	var wg sync.WaitGroup
+	for i, consumer := range consumers {
+		wg.Add(1)
+		go func(branch *Stream, consumer func(*Stream)) {
+			defer func() {
+				branch.open()
+				branch.cancel()
+				wg.Done()
+			}()
+			consumer(branch)
+		}(branches[i], consumer)
+	}
+	wg.Wait()
+	return s.Err()
+}
@@ -0,0 +1,89 @@
+package stream
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+// consume Returns the elements of the streams which are consumed concurrently.
+func consume(streams ...*Stream) [][]interface{} {
+	results := make([][]interface{}, len(streams))
+	var wg sync.WaitGroup
+	for i, stream := range streams {
+		wg.Add(1)
+		go func(i int, stream *Stream) {
+			defer wg.Done()
+			results[i] = make([]interface{}, 0)
+			for item := range stream.Chan() {
+				results[i] = append(results[i], item)
+			}
+		}(i, stream)
+	}
+	wg.Wait()
+	return results
+}
+
+func TestStream_Tee(t *testing.T) {
+	branches := Of(1, 2, 3).Tee(2)
+	assert.Equal(t, [][]interface{}{{1, 2, 3}, {1, 2, 3}}, consume(branches...))
+
+	branches = Of(1, 2, 3).Tee(2)
+	assert.Equal(t, [][]interface{}{{1}, {1, 2, 3}}, consume(branches[0].Limit(1), branches[1]))
+
+	stream, exited := infinite()
+	branches = stream.Tee(2)
+	assert.Equal(t, [][]interface{}{{0, 1}, {0}}, consume(branches[0].Limit(2), branches[1].Limit(1)))
+	<-exited
+
+	assert.Panics(t, func() {
+		Of(1).Tee(0)
+	})
+	assert.Panics(t, func() {
+		Of(1).Tee(1, WithBackpressure(BackpressureBuffer, -1))
+	})
+}
+
+func TestStream_TeeBackpressure(t *testing.T) {
+	// the branches are consumed one by one, since the elements are buffered.
+	branches := Of(1, 2, 3).Tee(2, WithBackpressure(BackpressureBuffer, 3))
+	equal(t, branches[0], []interface{}{1, 2, 3})
+	equal(t, branches[1], []interface{}{1, 2, 3})
+
+	// the elements are dropped once the buffer is full, the second branch is not read until the first one ends.
+	branches = Of(1, 2, 3).Tee(2, WithBackpressure(BackpressureDrop, 1))
+	assert.Equal(t, 1, <-branches[0].Chan())
+	drain(branches[0].Chan())
+	equal(t, branches[1], []interface{}{1})
+}
+
+func TestStream_Broadcast(t *testing.T) {
+	var sum, count int
+	err := Of(1, 2, 3).Broadcast([]func(*Stream){
+		func(s *Stream) {
+			_ = s.Foreach(func(item interface{}) {
+				sum += item.(int)
+			})
+		},
+		func(s *Stream) {
+			count = s.Count()
+		},
+		func(s *Stream) {
+			// the Stream is stopped, so the others are not blocked.
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 6, sum)
+	assert.Equal(t, 3, count)
+
+	errMap := errors.New("map")
+	err = Of(1).MapErr(func(item interface{}) (interface{}, error) {
+		return nil, errMap
+	}).Broadcast([]func(*Stream){
+		func(s *Stream) {
+			s.Finish()
+		},
+	})
+	assert.Equal(t, errMap, err)
+}
@@ -0,0 +1,214 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTimeout is the error of a Stream which received no element within the timeout.
+var ErrTimeout = errors.New("stream: timeout")
+
+// WithCompleteOnTimeout return a Option that completes the Stream instead of failing it with ErrTimeout on Timeout
+func WithCompleteOnTimeout() Option {
+	return func(options *Options) {
+		options.completeOnTimeout = true
+	}
+}
+
+// Debounce Returns a Stream that emits an element only if no other element is received within d after it,
+// the last element is emitted once the Stream ends.
+func (s *Stream) Debounce(d time.Duration, opts ...Option) *Stream {
+	if d <= 0 {
+		panic("d should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	return s.stage("Debounce", 0, func(ctx context.Context, source chan interface{}) {
+		var (
+			timer   Timer
+			fired   <-chan time.Time
+			pending interface{}
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					if fired != nil {
+						source <- pending
+					}
+					return
+				}
+				pending = item
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = option.clock.NewTimer(d)
+				fired = timer.C()
+			case <-fired:
+				fired = nil
+				source <- pending
+				pending = nil
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// ThrottleFirst Returns a Stream that emits the first element, and drops the elements received within d after it.
+func (s *Stream) ThrottleFirst(d time.Duration, opts ...Option) *Stream {
+	if d <= 0 {
+		panic("d should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	return s.stage("ThrottleFirst", 0, func(ctx context.Context, source chan interface{}) {
+		var last time.Time
+		for i := 0; ; i++ {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				return
+			}
+			now := option.clock.Now()
+			if i != 0 && now.Sub(last) < d {
+				continue
+			}
+			last = now
+			source <- item
+		}
+	})
+}
+
+// Sample Returns a Stream that emits the latest element received within each d,
+// the latest element is dropped if the Stream ends before it is sampled.
+func (s *Stream) Sample(d time.Duration, opts ...Option) *Stream {
+	if d <= 0 {
+		panic("d should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	return s.stage("Sample", 0, func(ctx context.Context, source chan interface{}) {
+		ticker := option.clock.NewTicker(d)
+		defer ticker.Stop()
+
+		var (
+			latest  interface{}
+			updated bool
+		)
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					return
+				}
+				latest, updated = item, true
+			case <-ticker.C():
+				if updated {
+					source <- latest
+					latest, updated = nil, false
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// Delay Returns a Stream that emits each element d after it is received, the order of the elements is kept.
+func (s *Stream) Delay(d time.Duration, opts ...Option) *Stream {
+	if d < 0 {
+		panic("d should be greater than -1")
+	}
+	option := loadOptions(opts...)
+	type delayed struct {
+		item  interface{}
+		timer Timer
+	}
+	return s.stage("Delay", 0, func(ctx context.Context, source chan interface{}) {
+		var queue []delayed
+		defer func() {
+			for _, e := range queue {
+				e.timer.Stop()
+			}
+		}()
+
+		upstream := s.source
+		for upstream != nil || len(queue) != 0 {
+			// the element at the head of queue is always the first one to be due.
+			var due <-chan time.Time
+			if len(queue) != 0 {
+				due = queue[0].timer.C()
+			}
+			select {
+			case item, ok := <-upstream:
+				if !ok {
+					upstream = nil
+					continue
+				}
+				queue = append(queue, delayed{item: item, timer: option.clock.NewTimer(d)})
+			case <-due:
+				item := queue[0].item
+				queue[0] = delayed{}
+				queue = queue[1:]
+				source <- item
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// Timeout Returns a Stream that fails with ErrTimeout if no element is received within d,
+// from the start of the Stream or the previous element. Use WithCompleteOnTimeout to complete the Stream instead.
+func (s *Stream) Timeout(d time.Duration, opts ...Option) *Stream {
+	if d <= 0 {
+		panic("d should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	return s.stage("Timeout", 0, func(ctx context.Context, source chan interface{}) {
+		timer := option.clock.NewTimer(d)
+		defer func() {
+			timer.Stop()
+		}()
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					return
+				}
+				timer.Stop()
+				source <- item
+				// the time spent by the downstream does not count.
+				timer = option.clock.NewTimer(d)
+			case <-timer.C():
+				if !option.completeOnTimeout {
+					s.pipeline.fail(ErrTimeout, option.errorMode == FailFast)
+				}
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStream_Debounce(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	stream := Range(upstream).Debounce(time.Second, WithClock(clock))
+	source := stream.Chan()
+
+	upstream <- 1
+	upstream <- 2
+	clock.waitTimers(2)
+	clock.Advance(time.Second)
+	assert.Equal(t, 2, <-source)
+
+	upstream <- 3
+	close(upstream)
+	assert.Equal(t, 3, <-source)
+	_, ok := <-source
+	assert.False(t, ok)
+	assert.NoError(t, stream.Err())
+
+	assert.Panics(t, func() {
+		Of(1).Debounce(0)
+	})
+}
+
+func TestStream_ThrottleFirst(t *testing.T) {
+	clock := newFakeClock()
+	clock.step = time.Second
+	equal(t, Of(1, 2, 3, 4, 5, 6, 7).ThrottleFirst(3*time.Second, WithClock(clock)), []interface{}{1, 4, 7})
+
+	assert.Panics(t, func() {
+		Of(1).ThrottleFirst(0)
+	})
+}
+
+func TestStream_Sample(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	source := Range(upstream).Sample(time.Second, WithClock(clock)).Chan()
+
+	upstream <- 1
+	upstream <- 2
+	clock.Advance(time.Second)
+	assert.Equal(t, 2, <-source)
+
+	upstream <- 3
+	close(upstream)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		Of(1).Sample(0)
+	})
+}
+
+func TestStream_Delay(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	source := Range(upstream).Delay(2*time.Second, WithClock(clock)).Chan()
+
+	upstream <- 1
+	clock.waitTimers(1)
+	clock.Advance(time.Second)
+	upstream <- 2
+	clock.waitTimers(2)
+	close(upstream)
+
+	clock.Advance(time.Second)
+	assert.Equal(t, 1, <-source)
+	clock.Advance(time.Second)
+	assert.Equal(t, 2, <-source)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	equal(t, Of(1, 2, 3).Delay(0), []interface{}{1, 2, 3})
+	assert.Panics(t, func() {
+		Of(1).Delay(-1)
+	})
+}
+
+func TestStream_Timeout(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	stream := Range(upstream).Timeout(time.Second, WithClock(clock))
+	source := stream.Chan()
+
+	upstream <- 1
+	assert.Equal(t, 1, <-source)
+	clock.waitTimers(2)
+	clock.Advance(time.Second)
+	_, ok := <-source
+	assert.False(t, ok)
+	assert.Equal(t, ErrTimeout, stream.Err())
+
+	clock = newFakeClock()
+	stream = Range(make(chan interface{})).Timeout(time.Second, WithClock(clock), WithCompleteOnTimeout())
+	source = stream.Chan()
+	clock.waitTimers(1)
+	clock.Advance(time.Second)
+	_, ok = <-source
+	assert.False(t, ok)
+	assert.NoError(t, stream.Err())
+
+	equal(t, Of(1, 2).Timeout(time.Second), []interface{}{1, 2})
+	assert.Panics(t, func() {
+		Of(1).Timeout(0)
+	})
+}
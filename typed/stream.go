@@ -0,0 +1,263 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+// Package typed provides a type-safe Stream based on generics.
+// A Stream of type T is built on the stages of stream.Stream, so it is lazy and accepts the same options,
+// and it shares the errors with the stream.Stream it is converted from or to.
+// The elements are still boxed into interface{} by the stages, the Stream only checks their types.
+package typed
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"stream"
+)
+
+// Stream Represents a stream of elements of type T.
+type Stream[T any] struct {
+	// stream holds the elements of type T.
+	stream *stream.Stream
+	// ctx is the context the Stream is bound to, which stops the forwarding of Chan.
+	ctx context.Context
+
+	once   sync.Once
+	source <-chan T
+}
+
+// newStream Returns a Stream of type T bound to ctx from s, whose elements are all of type T.
+func newStream[T any](ctx context.Context, s *stream.Stream) *Stream[T] {
+	return &Stream[T]{stream: s, ctx: ctx}
+}
+
+// Of Returns a Stream based any element.
+func Of[T any](items ...T) *Stream[T] {
+	boxed := make([]interface{}, len(items))
+	for i, item := range items {
+		boxed[i] = item
+	}
+	return newStream[T](context.Background(), stream.Of(boxed...))
+}
+
+// Range Returns a Stream from source channel.
+func Range[T any](source <-chan T) *Stream[T] {
+	return newStream[T](context.Background(), stream.FromContext(context.Background(), func(ctx context.Context, pipe chan<- interface{}) {
+		for {
+			select {
+			case item, ok := <-source:
+				if !ok {
+					return
+				}
+				pipe <- item
+			case <-ctx.Done():
+				return
+			}
+		}
+	}))
+}
+
+// From Returns a Stream from generate function.
+// The generate function can not be interrupted, it is left blocked on sending forever once the Stream is stopped.
+//
+// Deprecated: Use FromContext, whose generate function can return once the Stream is stopped.
+func From[T any](generate func(source chan<- T)) *Stream[T] {
+	return newStream[T](context.Background(), stream.From(func(source chan<- interface{}) {
+		forward(source, generate)
+	}))
+}
+
+// FromContext Returns a Stream bound to ctx from generate function.
+// The generate function should return as soon as ctx is done.
+func FromContext[T any](ctx context.Context, generate func(ctx context.Context, source chan<- T)) *Stream[T] {
+	return newStream[T](ctx, stream.FromContext(ctx, func(ctx context.Context, source chan<- interface{}) {
+		forward(source, func(typed chan<- T) {
+			generate(ctx, typed)
+		})
+	}))
+}
+
+// FromStream Returns a Stream of type T from s, which shares the errors with s.
+// The elements which are not of type T are dropped and fail the Stream.
+func FromStream[T any](s *stream.Stream) *Stream[T] {
+	return newStream[T](context.Background(), s.FilterErr(func(item interface{}) (bool, error) {
+		if _, ok := item.(T); !ok && (item != nil || !nilable[T]()) {
+			return false, fmt.Errorf("stream: element of type %T is not of type %v", item, reflect.TypeOf((*T)(nil)).Elem())
+		}
+		return true, nil
+	}, stream.WithErrorMode(stream.CollectAll)))
+}
+
+// Untyped Returns a untyped stream.Stream of s, which shares the errors with s.
+func (s *Stream[T]) Untyped() *stream.Stream {
+	return s.stream
+}
+
+// WithContext Returns a Stream bound to ctx.
+// Once ctx is done, all the stages of the Stream are stopped.
+func (s *Stream[T]) WithContext(ctx context.Context) *Stream[T] {
+	return newStream[T](ctx, s.stream.WithContext(ctx))
+}
+
+// Filter Returns a Stream that contains the elements matching the predicate fn.
+func (s *Stream[T]) Filter(fn func(item T) bool, opts ...stream.Option) *Stream[T] {
+	return newStream[T](s.ctx, s.stream.Filter(func(item interface{}) bool {
+		return fn(cast[T](item))
+	}, opts...))
+}
+
+// Peek Returns a Stream consisting of the elements of this stream,
+// additionally performing the provided action on each element as elements are consumed from the resulting stream.
+func (s *Stream[T]) Peek(fn func(item T)) *Stream[T] {
+	return newStream[T](s.ctx, s.stream.Peek(func(item interface{}) {
+		fn(cast[T](item))
+	}))
+}
+
+// Skip Returns a Stream that skips size elements.
+func (s *Stream[T]) Skip(size int) *Stream[T] {
+	return newStream[T](s.ctx, s.stream.Skip(size))
+}
+
+// Limit Returns a Stream that contains size elements.
+// The upstream is stopped once size elements are sent.
+func (s *Stream[T]) Limit(size int) *Stream[T] {
+	return newStream[T](s.ctx, s.stream.Limit(size))
+}
+
+// Chan Returns a channel of Stream.
+// The elements are forwarded to the channel from a new goroutine, which exits once the ctx
+// the Stream is bound to is done, so bind the Stream with WithContext and cancel it
+// if the channel may be abandoned before it is closed.
+func (s *Stream[T]) Chan() <-chan T {
+	s.once.Do(func() {
+		source := make(chan T)
+		go func() {
+			defer close(source)
+			for item := range s.stream.Chan() {
+				select {
+				case source <- cast[T](item):
+				case <-s.ctx.Done():
+					// the stages are stopped by ctx as well, and the elements left are drained.
+					return
+				}
+			}
+		}()
+		s.source = source
+	})
+	return s.source
+}
+
+// Foreach Traversals all elements, returns the error of the Stream.
+func (s *Stream[T]) Foreach(fn func(item T)) error {
+	return s.stream.Foreach(func(item interface{}) {
+		fn(cast[T](item))
+	})
+}
+
+// ToSlice Returns a slice of all elements, and the error of the Stream.
+func (s *Stream[T]) ToSlice() ([]T, error) {
+	var items []T
+	err := s.Foreach(func(item T) {
+		items = append(items, item)
+	})
+	return items, err
+}
+
+// Count Returns a number that the elements total size.
+// Use Err to check whether the Stream is failed.
+func (s *Stream[T]) Count() int {
+	return s.stream.Count()
+}
+
+// Err Returns the error of the Stream, it should be called after the Stream is consumed.
+func (s *Stream[T]) Err() error {
+	return s.stream.Err()
+}
+
+// Map Returns a Stream consisting of the results of applying the given
+// function to the elements of s.
+func Map[T, U any](s *Stream[T], fn func(item T) U, opts ...stream.Option) *Stream[U] {
+	return newStream[U](s.ctx, s.stream.Map(func(item interface{}) interface{} {
+		return fn(cast[T](item))
+	}, opts...))
+}
+
+// FlatMap Returns a Stream consisting of the results of replacing each element of s with
+// the elements of the slice produced by applying the given function to the element.
+func FlatMap[T, U any](s *Stream[T], fn func(item T) []U, opts ...stream.Option) *Stream[U] {
+	return newStream[U](s.ctx, s.stream.Walk(func(item interface{}, pipe chan<- interface{}) {
+		for _, v := range fn(cast[T](item)) {
+			pipe <- v
+		}
+	}, opts...))
+}
+
+// Reduce Returns the result of accumulating all elements of s into identity, and the error of the Stream.
+func Reduce[T, A any](s *Stream[T], identity A, accumulator func(acc A, item T) A) (A, error) {
+	acc := identity
+	err := s.Foreach(func(item T) {
+		acc = accumulator(acc, item)
+	})
+	return acc, err
+}
+
+// GroupBy Returns the elements of s grouped by their keys, and the error of the Stream.
+func GroupBy[T any, K comparable](s *Stream[T], key func(item T) K) (map[K][]T, error) {
+	groups := make(map[K][]T)
+	err := s.Foreach(func(item T) {
+		k := key(item)
+		groups[k] = append(groups[k], item)
+	})
+	return groups, err
+}
+
+// cast Returns item as type T.
+// A nil item is the nil value of T, since a nil interface value of type T is boxed to a nil interface{}.
+func cast[T any](item interface{}) T {
+	v, _ := item.(T)
+	return v
+}
+
+// nilable Returns whether T is an interface type, whose nil value is boxed to a nil interface{}.
+func nilable[T any]() bool {
+	return reflect.TypeOf((*T)(nil)).Elem().Kind() == reflect.Interface
+}
+
+// forward sends the elements sent by generate into source.
+// The generate function is run in a new goroutine since it sends into a typed channel,
+// its panic is raised again in the caller.
+func forward[T any](source chan<- interface{}, generate func(source chan<- T)) {
+	typed := make(chan T)
+	var recovered interface{}
+	go func() {
+		defer close(typed)
+		defer func() {
+			recovered = recover()
+		}()
+		generate(typed)
+	}()
+
+	for item := range typed {
+		source <- item
+	}
+	if recovered != nil {
+		panic(recovered)
+	}
+}
@@ -0,0 +1,247 @@
+package typed
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"stream"
+)
+
+func TestOf(t *testing.T) {
+	items, err := Of(1, 2, 3).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, items)
+	assert.Equal(t, 0, Of[int]().Count())
+}
+
+func TestFrom(t *testing.T) {
+	items, err := From(func(source chan<- int) {
+		for i := 0; i < 3; i++ {
+			source <- i
+		}
+	}).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, items)
+}
+
+func TestFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	s := FromContext(ctx, func(ctx context.Context, source chan<- int) {
+		defer close(exited)
+		for i := 0; ; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	assert.Equal(t, 0, <-s.Chan())
+	cancel()
+	<-exited
+	assert.Equal(t, context.Canceled, s.Foreach(func(item int) {}))
+}
+
+func TestStream_Limit(t *testing.T) {
+	exited := make(chan struct{})
+	items, err := FromContext(context.Background(), func(ctx context.Context, source chan<- int) {
+		defer close(exited)
+		for i := 0; ; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}).Skip(1).Limit(3).ToSlice()
+	<-exited
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, items)
+}
+
+func TestStream_Filter(t *testing.T) {
+	var peeked []int
+	items, err := Of(1, 2, 3, 4).Filter(func(item int) bool {
+		return item%2 == 0
+	}).Peek(func(item int) {
+		peeked = append(peeked, item)
+	}).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, items)
+	assert.Equal(t, []int{2, 4}, peeked)
+}
+
+func TestMap(t *testing.T) {
+	items, err := Map(Of(1, 2, 3), strconv.Itoa).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "3"}, items)
+}
+
+func TestFlatMap(t *testing.T) {
+	items, err := FlatMap(Of("a,b", "c"), func(item string) []rune {
+		return []rune(item)
+	}).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []rune("a,bc"), items)
+}
+
+func TestReduce(t *testing.T) {
+	sum, err := Reduce(Of(1, 2, 3, 4), 0.5, func(acc float64, item int) float64 {
+		return acc + float64(item)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 10.5, sum)
+}
+
+func TestGroupBy(t *testing.T) {
+	groups, err := GroupBy(Of("a", "bb", "cc", "d"), func(item string) int {
+		return len(item)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int][]string{1: {"a", "d"}, 2: {"bb", "cc"}}, groups)
+}
+
+func TestFromStream(t *testing.T) {
+	items, err := FromStream[int](stream.Of(1, 2, 3)).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, items)
+
+	items, err = FromStream[int](stream.Of(1, "2", 3)).ToSlice()
+	assert.Error(t, err)
+	assert.Equal(t, []int{1, 3}, items)
+
+	errMap := errors.New("map")
+	_, err = FromStream[int](stream.Of(1).MapErr(func(item interface{}) (interface{}, error) {
+		return nil, errMap
+	})).ToSlice()
+	assert.Equal(t, errMap, err)
+}
+
+func TestStream_NilInterface(t *testing.T) {
+	errFoo := errors.New("foo")
+	assert.Equal(t, 2, Of[error](nil, errFoo).Count())
+
+	items, err := Of[error](nil, errFoo).Filter(func(item error) bool {
+		return item == nil
+	}).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil}, items)
+
+	results, err := Map(Of[any](nil), func(item any) bool {
+		return item == nil
+	}).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true}, results)
+
+	items, err = FromStream[error](stream.Of(nil, errFoo)).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []error{nil, errFoo}, items)
+
+	_, err = FromStream[int](stream.Of(nil)).ToSlice()
+	assert.Error(t, err)
+}
+
+func TestStream_Untyped(t *testing.T) {
+	var items []interface{}
+	err := Of(3, 1, 2).Untyped().Sort(func(a, b interface{}) bool {
+		return a.(int) < b.(int)
+	}).Foreach(func(item interface{}) {
+		items = append(items, item)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2, 3}, items)
+
+	result, err := Map(FromStream[int](Of(1, 2, 3).Untyped()), func(item int) int {
+		return item * 2
+	}).ToSlice()
+	assert.NoError(t, err)
+	sort.Ints(result)
+	assert.Equal(t, []int{2, 4, 6}, result)
+}
+
+func TestStream_UntypedErr(t *testing.T) {
+	s := FromStream[int](stream.Of(1, "a", 3)).Untyped()
+	assert.Equal(t, 2, s.Count())
+	assert.Error(t, s.Err())
+}
+
+func TestRange(t *testing.T) {
+	source := make(chan int, 2)
+	source <- 1
+	source <- 2
+	close(source)
+	items, err := Range[int](source).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, items)
+}
+
+func TestStream_Lazy(t *testing.T) {
+	var called int32
+	s := Map(Of(1, 2, 3), func(item int) int {
+		atomic.AddInt32(&called, 1)
+		return item
+	})
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&called))
+	assert.Equal(t, 3, s.Count())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&called))
+}
+
+func TestMap_Options(t *testing.T) {
+	items, err := Map(Of(1, 2, 3, 4), func(item int) int {
+		time.Sleep(time.Duration(5-item) * time.Millisecond)
+		return item * 2
+	}, stream.WithWorkSize(4), stream.WithOrdered()).ToSlice()
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 6, 8}, items)
+
+	items, err = Map(Of(1, 2, 3), func(item int) int {
+		if item == 2 {
+			panic("two")
+		}
+		return item
+	}, stream.WithPanicError(), stream.WithErrorMode(stream.CollectAll)).ToSlice()
+	assert.Equal(t, &stream.PanicError{Item: 2, Recovered: "two"}, err)
+	assert.Equal(t, []int{1, 3}, items)
+}
+
+func TestStream_WithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	exited := make(chan struct{})
+	s := FromContext(context.Background(), func(ctx context.Context, source chan<- int) {
+		defer close(exited)
+		for i := 0; i < 100; i++ {
+			select {
+			case source <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}).WithContext(ctx)
+	assert.Equal(t, 0, <-s.Chan())
+	cancel()
+	<-exited
+	assert.Equal(t, context.Canceled, s.Foreach(func(item int) {}))
+}
+
+func TestStream_ChanAbandoned(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := Map(Of(1, 2, 3), func(item int) int {
+		return item
+	}).WithContext(ctx)
+	assert.Equal(t, 1, <-s.Chan())
+	cancel()
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
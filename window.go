@@ -0,0 +1,238 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import (
+	"context"
+	"time"
+)
+
+// TumblingWindow Returns a Stream of windows, each window is a []interface{} of count consecutive elements,
+// the last window may contain fewer elements.
+func (s *Stream) TumblingWindow(count int) *Stream {
+	if count < 1 {
+		panic("count should be greater than 0")
+	}
+	return s.stage("TumblingWindow", 0, func(ctx context.Context, source chan interface{}) {
+		window := make([]interface{}, 0, count)
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				break
+			}
+			window = append(window, item)
+			if len(window) == count {
+				source <- window
+				window = make([]interface{}, 0, count)
+			}
+		}
+		if len(window) != 0 && ctx.Err() == nil {
+			source <- window
+		}
+	})
+}
+
+// SlidingWindow Returns a Stream of windows, each window is a []interface{} of size consecutive elements,
+// and a window starts every step elements. The elements between two windows are dropped if step is greater than size.
+// If the Stream has fewer than size elements, they are emitted as one window.
+func (s *Stream) SlidingWindow(size, step int) *Stream {
+	if size < 1 {
+		panic("size should be greater than 0")
+	}
+	if step < 1 {
+		panic("step should be greater than 0")
+	}
+	return s.stage("SlidingWindow", 0, func(ctx context.Context, source chan interface{}) {
+		ring := NewRing(size)
+		count := 0
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				break
+			}
+			ring.Add(item)
+			count++
+			if count >= size && (count-size)%step == 0 {
+				source <- ring.Take()
+			}
+		}
+		if count != 0 && count < size && ctx.Err() == nil {
+			source <- ring.Take()
+		}
+	})
+}
+
+// TimeWindow Returns a Stream of windows, each window is a []interface{} of the elements received within d.
+// The empty windows are not emitted.
+func (s *Stream) TimeWindow(d time.Duration, opts ...Option) *Stream {
+	if d <= 0 {
+		panic("d should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	return s.stage("TimeWindow", 0, func(ctx context.Context, source chan interface{}) {
+		ticker := option.clock.NewTicker(d)
+		defer ticker.Stop()
+
+		var window []interface{}
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					if len(window) != 0 {
+						source <- window
+					}
+					return
+				}
+				window = append(window, item)
+			case <-ticker.C():
+				if len(window) != 0 {
+					source <- window
+					window = nil
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// SlidingTimeWindow Returns a Stream of windows, every slide a window is emitted which is a []interface{}
+// of the elements received within the last size. The empty windows are not emitted, and the elements
+// received after the last window are emitted as a window of the last size when the Stream ends.
+func (s *Stream) SlidingTimeWindow(size, slide time.Duration, opts ...Option) *Stream {
+	if size <= 0 {
+		panic("size should be greater than 0")
+	}
+	if slide <= 0 {
+		panic("slide should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	type element struct {
+		item interface{}
+		at   time.Time
+	}
+	return s.stage("SlidingTimeWindow", 0, func(ctx context.Context, source chan interface{}) {
+		ticker := option.clock.NewTicker(slide)
+		defer ticker.Stop()
+
+		var (
+			elements []element
+			// pending reports whether any element is received after the last window.
+			pending bool
+		)
+		// emit emits the elements received after start.
+		emit := func(start time.Time) {
+			pending = false
+			i := 0
+			for i < len(elements) && !elements[i].at.After(start) {
+				elements[i] = element{}
+				i++
+			}
+			elements = elements[i:]
+			if len(elements) == 0 {
+				return
+			}
+			window := make([]interface{}, len(elements))
+			for j, e := range elements {
+				window[j] = e.item
+			}
+			source <- window
+		}
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					if pending {
+						emit(option.clock.Now().Add(-size))
+					}
+					return
+				}
+				elements = append(elements, element{item: item, at: option.clock.Now()})
+				pending = true
+			case now := <-ticker.C():
+				emit(now.Add(-size))
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// SessionWindow Returns a Stream of windows, each window is a []interface{} of the elements
+// whose intervals are less than gap, that is, a window is closed if no element is received within gap.
+func (s *Stream) SessionWindow(gap time.Duration, opts ...Option) *Stream {
+	if gap <= 0 {
+		panic("gap should be greater than 0")
+	}
+	option := loadOptions(opts...)
+	return s.stage("SessionWindow", 0, func(ctx context.Context, source chan interface{}) {
+		var (
+			timer  Timer
+			closed <-chan time.Time
+			window []interface{}
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+		for {
+			select {
+			case item, ok := <-s.source:
+				if !ok {
+					if len(window) != 0 {
+						source <- window
+					}
+					return
+				}
+				window = append(window, item)
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = option.clock.NewTimer(gap)
+				closed = timer.C()
+			case <-closed:
+				closed = nil
+				source <- window
+				window = nil
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// Streams Returns a Stream that replaces each []interface{} element, such as a window, with a Stream of its elements.
+func (s *Stream) Streams() *Stream {
+	return s.stage("Streams", 0, func(ctx context.Context, source chan interface{}) {
+		for {
+			item, ok := receive(ctx, s.source)
+			if !ok {
+				return
+			}
+			items := item.([]interface{})
+			chunkSource := make(chan interface{}, len(items))
+			for _, v := range items {
+				chunkSource <- v
+			}
+			close(chunkSource)
+			source <- s.derive(chunkSource)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func windows(s *Stream) []interface{} {
+	var result []interface{}
+	for window := range s.Chan() {
+		result = append(result, window)
+	}
+	return result
+}
+
+func TestStream_TumblingWindow(t *testing.T) {
+	assertEqual(t, windows(Of(1, 2, 3, 4, 5).TumblingWindow(2)), []interface{}{
+		[]interface{}{1, 2}, []interface{}{3, 4}, []interface{}{5},
+	})
+	assert.Empty(t, windows(Empty().TumblingWindow(2)))
+	assert.Panics(t, func() {
+		Of(1).TumblingWindow(0)
+	})
+}
+
+func TestStream_SlidingWindow(t *testing.T) {
+	assertEqual(t, windows(Of(1, 2, 3, 4, 5).SlidingWindow(3, 1)), []interface{}{
+		[]interface{}{1, 2, 3}, []interface{}{2, 3, 4}, []interface{}{3, 4, 5},
+	})
+	assertEqual(t, windows(Of(1, 2, 3, 4, 5).SlidingWindow(2, 2)), []interface{}{
+		[]interface{}{1, 2}, []interface{}{3, 4},
+	})
+	assertEqual(t, windows(Of(1, 2, 3, 4, 5, 6).SlidingWindow(2, 3)), []interface{}{
+		[]interface{}{1, 2}, []interface{}{4, 5},
+	})
+	assertEqual(t, windows(Of(1, 2).SlidingWindow(3, 1)), []interface{}{
+		[]interface{}{1, 2},
+	})
+	assert.Panics(t, func() {
+		Of(1).SlidingWindow(0, 1)
+	})
+	assert.Panics(t, func() {
+		Of(1).SlidingWindow(1, 0)
+	})
+}
+
+func TestStream_TimeWindow(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	source := Range(upstream).TimeWindow(time.Minute, WithClock(clock)).Chan()
+
+	upstream <- 1
+	upstream <- 2
+	clock.Advance(time.Minute)
+	assert.Equal(t, []interface{}{1, 2}, <-source)
+
+	clock.Advance(time.Minute)
+	upstream <- 3
+	close(upstream)
+	assert.Equal(t, []interface{}{3}, <-source)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		Of(1).TimeWindow(0)
+	})
+}
+
+func TestStream_SlidingTimeWindow(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	source := Range(upstream).SlidingTimeWindow(2*time.Minute, time.Minute, WithClock(clock)).Chan()
+
+	upstream <- 0
+	clock.waitNow(1)
+	clock.Advance(10 * time.Second)
+	upstream <- 1
+	clock.waitNow(2)
+	clock.Advance(50 * time.Second)
+	assert.Equal(t, []interface{}{0, 1}, <-source)
+
+	clock.Advance(30 * time.Second)
+	upstream <- 2
+	clock.waitNow(3)
+	clock.Advance(30 * time.Second)
+	// the window is (0, 2m], the element received at 0 is evicted.
+	assert.Equal(t, []interface{}{1, 2}, <-source)
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, []interface{}{2}, <-source)
+
+	close(upstream)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		Of(1).SlidingTimeWindow(time.Minute, 0)
+	})
+	assert.Panics(t, func() {
+		Of(1).SlidingTimeWindow(0, time.Minute)
+	})
+}
+
+func TestStream_SlidingTimeWindowUneven(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	source := Range(upstream).SlidingTimeWindow(3*time.Minute, 2*time.Minute, WithClock(clock)).Chan()
+
+	upstream <- 1
+	clock.waitNow(1)
+	clock.Advance(90 * time.Second)
+	upstream <- 2
+	clock.waitNow(2)
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, []interface{}{1, 2}, <-source)
+
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, []interface{}{2}, <-source)
+
+	// the elements received after the last window are emitted when the Stream ends.
+	clock.Advance(30 * time.Second)
+	upstream <- 3
+	close(upstream)
+	assert.Equal(t, []interface{}{3}, <-source)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	// the elements between the windows are dropped if size is less than slide.
+	clock = newFakeClock()
+	upstream = make(chan interface{})
+	source = Range(upstream).SlidingTimeWindow(time.Minute, 2*time.Minute, WithClock(clock)).Chan()
+	upstream <- 1
+	clock.waitNow(1)
+	clock.Advance(90 * time.Second)
+	upstream <- 2
+	clock.waitNow(2)
+	clock.Advance(30 * time.Second)
+	assert.Equal(t, []interface{}{2}, <-source)
+	close(upstream)
+	_, ok = <-source
+	assert.False(t, ok)
+
+	// the tiny slides need no memory but the elements.
+	equal(t, Of(1, 2).SlidingTimeWindow(time.Minute, time.Second/3), []interface{}{[]interface{}{1, 2}})
+}
+
+func TestStream_SessionWindow(t *testing.T) {
+	clock := newFakeClock()
+	upstream := make(chan interface{})
+	source := Range(upstream).SessionWindow(time.Minute, WithClock(clock)).Chan()
+
+	upstream <- 1
+	upstream <- 2
+	clock.waitTimers(2)
+	clock.Advance(time.Minute)
+	assert.Equal(t, []interface{}{1, 2}, <-source)
+
+	upstream <- 3
+	close(upstream)
+	assert.Equal(t, []interface{}{3}, <-source)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	assert.Panics(t, func() {
+		Of(1).SessionWindow(0)
+	})
+}
+
+func TestStream_Streams(t *testing.T) {
+	streams := Of(1, 2, 3).TumblingWindow(2).Streams()
+	equal(t, (<-streams.Chan()).(*Stream), []interface{}{1, 2})
+	equal(t, (<-streams.Chan()).(*Stream), []interface{}{3})
+}
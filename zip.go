@@ -0,0 +1,128 @@
+/*
+ *
+ *     Copyright 2021 chenquan
+ *
+ *     Licensed under the Apache License, Version 2.0 (the "License");
+ *     you may not use this file except in compliance with the License.
+ *     You may obtain a copy of the License at
+ *
+ *         http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *     Unless required by applicable law or agreed to in writing, software
+ *     distributed under the License is distributed on an "AS IS" BASIS,
+ *     WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *     See the License for the specific language governing permissions and
+ *     limitations under the License.
+ *
+ */
+
+package stream
+
+import "context"
+
+// ZipFunc defines the method to combine the elements from multiple Streams.
+type ZipFunc func(items []interface{}) interface{}
+
+// Zip Returns a Stream of []interface{} tuples, the i-th tuple consists of the i-th elements of the given Streams
+// in order. The Stream ends when the shortest one ends.
+func Zip(a *Stream, others ...*Stream) *Stream {
+	return a.Zip(others...)
+}
+
+// ZipWith Returns a Stream like Zip, the tuples are combined by fn.
+func ZipWith(fn ZipFunc, a *Stream, others ...*Stream) *Stream {
+	return a.ZipWith(fn, others...)
+}
+
+// CombineLatest Returns a Stream of []interface{} tuples which consist of the latest elements of the given Streams.
+// A tuple is emitted whenever any Stream emits an element, once all the Streams have emitted.
+// The Stream ends when all the Streams end, or any of them ends without emitting.
+func CombineLatest(a *Stream, others ...*Stream) *Stream {
+	return a.CombineLatest(others...)
+}
+
+// Zip Returns a Stream of []interface{} tuples of s and others, see Zip.
+func (s *Stream) Zip(others ...*Stream) *Stream {
+	return s.zip("Zip", func(items []interface{}) interface{} {
+		return items
+	}, others)
+}
+
+// ZipWith Returns a Stream of the tuples of s and others combined by fn, see ZipWith.
+func (s *Stream) ZipWith(fn ZipFunc, others ...*Stream) *Stream {
+	return s.zip("ZipWith", fn, others)
+}
+
+// zip Returns a Stream of the tuples of s and others combined by fn.
+func (s *Stream) zip(operator string, fn ZipFunc, others []*Stream) *Stream {
+	streams := append([]*Stream{s}, others...)
+	return newStage(s.pipeline, operator, 0, func(ctx context.Context, source chan interface{}) {
+		for {
+			items := make([]interface{}, len(streams))
+			for i, stream := range streams {
+				item, ok := receive(ctx, stream.source)
+				if !ok {
+					return
+				}
+				items[i] = item
+			}
+			source <- fn(items)
+		}
+	}, streams...)
+}
+
+// CombineLatest Returns a Stream of the tuples of the latest elements of s and others, see CombineLatest.
+func (s *Stream) CombineLatest(others ...*Stream) *Stream {
+	streams := append([]*Stream{s}, others...)
+	type update struct {
+		index int
+		item  interface{}
+		ok    bool
+	}
+	return newStage(s.pipeline, "CombineLatest", 0, func(ctx context.Context, source chan interface{}) {
+		updates := make(chan update)
+		for i, stream := range streams {
+			go func(index int, stream *Stream) {
+				for {
+					item, ok := receive(ctx, stream.source)
+					select {
+					case updates <- update{index: index, item: item, ok: ok}:
+					case <-ctx.Done():
+						return
+					}
+					if !ok {
+						return
+					}
+				}
+			}(i, stream)
+		}
+
+		latest := make([]interface{}, len(streams))
+		emitted := make([]bool, len(streams))
+		var emittedCount, endedCount int
+		for endedCount < len(streams) {
+			var u update
+			select {
+			case u = <-updates:
+			case <-ctx.Done():
+				return
+			}
+			if !u.ok {
+				if !emitted[u.index] {
+					return
+				}
+				endedCount++
+				continue
+			}
+
+			if !emitted[u.index] {
+				emitted[u.index] = true
+				emittedCount++
+			}
+			latest[u.index] = u.item
+			if emittedCount == len(streams) {
+				source <- append([]interface{}(nil), latest...)
+			}
+		}
+	}, streams...)
+}
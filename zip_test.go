@@ -0,0 +1,52 @@
+package stream
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestZip(t *testing.T) {
+	equal(t, Zip(Of(1, 2, 3), Of("a", "b"), Of(true, false, true)), []interface{}{
+		[]interface{}{1, "a", true}, []interface{}{2, "b", false},
+	})
+	equal(t, Of(1, 2).Zip(), []interface{}{
+		[]interface{}{1}, []interface{}{2},
+	})
+	equal(t, Zip(Of(1, 2), Empty()), []interface{}{})
+
+	stream, exited := infinite()
+	equal(t, Zip(stream, Of("a")), []interface{}{
+		[]interface{}{0, "a"},
+	})
+	<-exited
+}
+
+func TestZipWith(t *testing.T) {
+	sum := func(items []interface{}) interface{} {
+		return items[0].(int) + items[1].(int)
+	}
+	equal(t, ZipWith(sum, Of(1, 2, 3), Of(10, 20, 30)), []interface{}{11, 22, 33})
+	equal(t, Of(1, 2).ZipWith(sum, Of(10)), []interface{}{11})
+}
+
+func TestCombineLatest(t *testing.T) {
+	a := make(chan interface{})
+	b := make(chan interface{})
+	source := CombineLatest(Range(a), Range(b)).Chan()
+
+	a <- 1
+	b <- "x"
+	assert.Equal(t, []interface{}{1, "x"}, <-source)
+	a <- 2
+	assert.Equal(t, []interface{}{2, "x"}, <-source)
+	close(a)
+	b <- "y"
+	assert.Equal(t, []interface{}{2, "y"}, <-source)
+	close(b)
+	_, ok := <-source
+	assert.False(t, ok)
+
+	stream, exited := infinite()
+	equal(t, Of(1).CombineLatest(Empty(), stream), []interface{}{})
+	<-exited
+}